@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"math/bits"
+	"slices"
+)
+
+// truthVectorLimit is the largest number of identifiers for which a truth
+// vector is computed.
+const truthVectorLimit = 20
+
+// truthVector evaluates the expression under every assignment of the
+// identifiers, bit i of an index is the value of idents[i].
+func truthVector(expression ast.Expression, idents []string) ([]bool, error) {
+	if len(idents) > truthVectorLimit {
+		return nil, fmt.Errorf("the formula has %d identifiers, at most %d are supported", len(idents), truthVectorLimit)
+	}
+	vector := make([]bool, 1<<len(idents))
+	input := make(map[string]bool, len(idents))
+	for index := range vector {
+		for i, ident := range idents {
+			input[ident] = index&(1<<i) != 0
+		}
+		vector[index] = evaluateExpression(input, expression)
+	}
+	return vector, nil
+}
+
+// ANF returns the algebraic normal form of the expression, its Zhegalkin
+// polynomial: the exclusive or of conjunctions of identifiers, with 1 for
+// the empty conjunction. The coefficients are the Möbius transform of the
+// truth vector. The second result is the algebraic degree, the size of the
+// largest conjunction.
+func ANF(expression ast.Expression) (ast.Expression, int, error) {
+	idents := getAllIdentifiers(expression, nil)
+	coefficients, err := truthVector(expression, idents)
+	if err != nil {
+		return nil, 0, err
+	}
+	mobius(coefficients, len(idents))
+
+	monomials := make([]int, 0)
+	for index, coefficient := range coefficients {
+		if coefficient {
+			monomials = append(monomials, index)
+		}
+	}
+	// constant first, then by degree and the order of the identifiers
+	slices.SortFunc(monomials, func(a, b int) int {
+		if degree := bits.OnesCount(uint(a)) - bits.OnesCount(uint(b)); degree != 0 {
+			return degree
+		}
+		// the monomial with the first identifier they do not share goes first
+		difference := a ^ b
+		if a&difference&-difference != 0 {
+			return -1
+		}
+		return 1
+	})
+
+	degree := 0
+	var result ast.Expression
+	for _, monomial := range monomials {
+		degree = max(degree, bits.OnesCount(uint(monomial)))
+		term := anfMonomial(monomial, idents)
+		if result == nil {
+			result = term
+			continue
+		}
+		result = &ast.InfixExpression{Op: "^", Action: "xor", Left: result, Right: term}
+	}
+	if result == nil {
+		result = &ast.Boolean{Value: false}
+	}
+	return result, degree, nil
+}
+
+// mobius turns a truth vector over arity identifiers into the coefficients
+// of its Zhegalkin polynomial in place, coefficient i belongs to the
+// conjunction of the identifiers whose bits are set in i.
+func mobius(vector []bool, arity int) {
+	for i := 0; i < arity; i++ {
+		for index := range vector {
+			if index&(1<<i) != 0 {
+				vector[index] = vector[index] != vector[index^(1<<i)]
+			}
+		}
+	}
+}
+
+// anfMonomial builds the conjunction of the identifiers whose bits are set.
+func anfMonomial(monomial int, idents []string) ast.Expression {
+	var result ast.Expression
+	for i, ident := range idents {
+		if monomial&(1<<i) == 0 {
+			continue
+		}
+		factor := &ast.Identifier{Value: ident}
+		if result == nil {
+			result = factor
+			continue
+		}
+		result = &ast.InfixExpression{Op: "*", Action: "and", Left: result, Right: factor}
+	}
+	if result == nil {
+		return &ast.Boolean{Value: true}
+	}
+	return result
+}
+
+func formatANF(expression ast.Expression) string {
+	anf, degree, err := ANF(expression)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("%s\n%s %d", anf.Literal(), bold("algebraic degree"), degree)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// anfMonomials returns the conjunctions of a polynomial as sets of
+// identifier bits, failing when it is not an exclusive or of conjunctions.
+func anfMonomials(t *testing.T, expression ast.Expression, idents []string) []int {
+	t.Helper()
+	switch expr := expression.(type) {
+	case *ast.Boolean:
+		if expr.Value {
+			return []int{0}
+		}
+		return nil
+	case *ast.InfixExpression:
+		if expr.Action == "xor" {
+			return append(anfMonomials(t, expr.Left, idents), anfMonomials(t, expr.Right, idents)...)
+		}
+	}
+	monomial := 0
+	for factors := []ast.Expression{expression}; len(factors) > 0; {
+		factor := factors[len(factors)-1]
+		factors = factors[:len(factors)-1]
+		switch expr := factor.(type) {
+		case *ast.Identifier:
+			monomial |= 1 << slices.Index(idents, expr.Value)
+		case *ast.InfixExpression:
+			if expr.Action != "and" {
+				t.Fatalf("%s is not a conjunction of identifiers", expression.Literal())
+			}
+			factors = append(factors, expr.Left, expr.Right)
+		default:
+			t.Fatalf("%s is not a conjunction of identifiers", expression.Literal())
+		}
+	}
+	return []int{monomial}
+}
+
+func TestANF(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		data := make([]byte, r.Intn(20))
+		r.Read(data)
+		expression := fuzzExpression(data)
+		polynomial, degree, err := ANF(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		idents := getAllIdentifiers(expression, nil)
+		want, _ := truthVector(expression, idents)
+		got, _ := truthVector(polynomial, idents)
+		if !slices.Equal(got, want) {
+			t.Fatalf("%s has the inequivalent ANF %s", expression.Literal(), polynomial.Literal())
+		}
+
+		monomials := anfMonomials(t, polynomial, idents)
+		largest := 0
+		for _, monomial := range monomials {
+			largest = max(largest, bits.OnesCount(uint(monomial)))
+		}
+		if largest != degree {
+			t.Fatalf("%s has the ANF %s of degree %d, reported %d", expression.Literal(), polynomial.Literal(), largest, degree)
+		}
+		slices.Sort(monomials)
+		if len(slices.Compact(monomials)) != len(monomials) {
+			t.Fatalf("%s has the ANF %s with a repeated conjunction", expression.Literal(), polynomial.Literal())
+		}
+	}
+
+	cases := map[string]string{
+		"a + b":   "((a ^ b) ^ (a * b))",
+		"!a":      "(1 ^ a)",
+		"a ^ a":   "0",
+		"a <-> b": "((1 ^ a) ^ b)",
+	}
+	for source, want := range cases {
+		if got, _, _ := ANF(parseRuleSide(source)); got.Literal() != want {
+			t.Errorf("the ANF of %s is %s, want %s", source, got.Literal(), want)
+		}
+	}
+}
@@ -48,13 +48,30 @@ func (s *Boolean) Literal() string {
 	}
 }
 
+// SimplifyStatement asks for a simplified expression. Checked tests every
+// rewrite for equivalence. Explain names the format of the rule trace to
+// print instead, "text", "json" or "latex", and is empty when no trace is
+// wanted.
 type SimplifyStatement struct {
 	Token      *tokenizer.Token
 	Expression Expression
+	Checked    bool
+	Explain    string
 }
 
 func (s *SimplifyStatement) Literal() string {
-	return fmt.Sprintf("simplify %s", s.Expression.Literal())
+	result := fmt.Sprintf("simplify %s", s.Expression.Literal())
+	if s.Checked {
+		result += " checked"
+	}
+	switch s.Explain {
+	case "":
+	case "text":
+		result += " explain"
+	default:
+		result += " explain " + s.Explain
+	}
+	return result
 }
 
 type TableStatement struct {
@@ -66,6 +83,334 @@ func (s *TableStatement) Literal() string {
 	return fmt.Sprintf("table %s", s.Expression.Literal())
 }
 
+type SatStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *SatStatement) Literal() string {
+	return fmt.Sprintf("sat %s", s.Expression.Literal())
+}
+
+type ModelsStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+	Projection []*Identifier
+}
+
+func (s *ModelsStatement) Literal() string {
+	return fmt.Sprintf("models %s%s", s.Expression.Literal(), projectionLiteral(s.Projection))
+}
+
+type CountStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+	Projection []*Identifier
+}
+
+func (s *CountStatement) Literal() string {
+	return fmt.Sprintf("count %s%s", s.Expression.Literal(), projectionLiteral(s.Projection))
+}
+
+type ValidStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *ValidStatement) Literal() string {
+	return fmt.Sprintf("valid %s", s.Expression.Literal())
+}
+
+type UnsatStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *UnsatStatement) Literal() string {
+	return fmt.Sprintf("unsat %s", s.Expression.Literal())
+}
+
+type ClassifyStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *ClassifyStatement) Literal() string {
+	return fmt.Sprintf("classify %s", s.Expression.Literal())
+}
+
+type EntailmentStatement struct {
+	Token      *tokenizer.Token
+	Premises   []Expression
+	Conclusion Expression
+}
+
+func (s *EntailmentStatement) Literal() string {
+	return fmt.Sprintf("premises %s |- %s", expressionsLiteral(s.Premises), s.Conclusion.Literal())
+}
+
+type ConsistentStatement struct {
+	Token    *tokenizer.Token
+	Formulas []Expression
+}
+
+func (s *ConsistentStatement) Literal() string {
+	return fmt.Sprintf("consistent %s", expressionsLiteral(s.Formulas))
+}
+
+type ProveStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *ProveStatement) Literal() string {
+	return fmt.Sprintf("prove %s", s.Expression.Literal())
+}
+
+type TableauStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+	DOT        bool
+}
+
+func (s *TableauStatement) Literal() string {
+	if s.DOT {
+		return fmt.Sprintf("tableau dot %s", s.Expression.Literal())
+	}
+	return fmt.Sprintf("tableau %s", s.Expression.Literal())
+}
+
+// Span is a range of rune offsets into the source of a statement, End is
+// exclusive.
+type Span struct {
+	Start int
+	End   int
+}
+
+type CoreStatement struct {
+	Token    *tokenizer.Token
+	Formulas []Expression
+	Spans    []Span
+	Sources  []string
+}
+
+func (s *CoreStatement) Literal() string {
+	return fmt.Sprintf("core %s", expressionsLiteral(s.Formulas))
+}
+
+type EquivalenceStatement struct {
+	Token   *tokenizer.Token
+	Left    Expression
+	Right   Expression
+	Negated bool
+}
+
+func (s *EquivalenceStatement) Literal() string {
+	op := "=="
+	if s.Negated {
+		op = "!="
+	}
+	return fmt.Sprintf("%s %s %s", s.Left.Literal(), op, s.Right.Literal())
+}
+
+type BDDStatsStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *BDDStatsStatement) Literal() string {
+	return fmt.Sprintf("bdd stats %s", s.Expression.Literal())
+}
+
+type ExportStatement struct {
+	Token      *tokenizer.Token
+	Format     string
+	Expression Expression
+	Path       string
+}
+
+func (s *ExportStatement) Literal() string {
+	if s.Path == "" {
+		return fmt.Sprintf("export %s %s", s.Format, s.Expression.Literal())
+	}
+	return fmt.Sprintf("export %s %s to %s", s.Format, s.Expression.Literal(), s.Path)
+}
+
+type ImportStatement struct {
+	Token  *tokenizer.Token
+	Format string
+	Path   string
+}
+
+func (s *ImportStatement) Literal() string {
+	return fmt.Sprintf("import %s %s", s.Format, s.Path)
+}
+
+type CheckStatement struct {
+	Token *tokenizer.Token
+	Path  string
+}
+
+func (s *CheckStatement) Literal() string {
+	return fmt.Sprintf("check %s", s.Path)
+}
+
+// DerivationStatement is a chain of equal expressions, Laws[i] names the law
+// justifying the step from Steps[i-1] to Steps[i] and is empty when none is
+// given. Laws[0] is always empty.
+type DerivationStatement struct {
+	Token *tokenizer.Token
+	Steps []Expression
+	Laws  []string
+}
+
+func (s *DerivationStatement) Literal() string {
+	result := "derive " + s.Steps[0].Literal()
+	for i := 1; i < len(s.Steps); i++ {
+		result += " = " + s.Steps[i].Literal()
+		if s.Laws[i] != "" {
+			result += " by " + s.Laws[i]
+		}
+	}
+	return result
+}
+
+type RuleStatement struct {
+	Token       *tokenizer.Token
+	Name        string
+	Pattern     Expression
+	Replacement Expression
+}
+
+func (s *RuleStatement) Literal() string {
+	return fmt.Sprintf("rule %s: %s => %s", s.Name, s.Pattern.Literal(), s.Replacement.Literal())
+}
+
+type LoadStatement struct {
+	Token *tokenizer.Token
+	Path  string
+}
+
+func (s *LoadStatement) Literal() string {
+	return fmt.Sprintf("load %s", s.Path)
+}
+
+// SaturateStatement asks for the cheapest equivalent of an expression found
+// by equality saturation, Cost names the cost model and is empty for the
+// default one.
+type SaturateStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+	Cost       string
+}
+
+func (s *SaturateStatement) Literal() string {
+	if s.Cost == "" {
+		return fmt.Sprintf("saturate %s", s.Expression.Literal())
+	}
+	return fmt.Sprintf("saturate %s by %s", s.Expression.Literal(), s.Cost)
+}
+
+type NNFStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *NNFStatement) Literal() string {
+	return fmt.Sprintf("nnf %s", s.Expression.Literal())
+}
+
+type ANFStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *ANFStatement) Literal() string {
+	return fmt.Sprintf("anf %s", s.Expression.Literal())
+}
+
+// UniversalStatement asks for the expression rewritten with a single
+// universal gate, Gate is "nand" or "nor". Shared lists repeated subcircuits
+// once.
+type UniversalStatement struct {
+	Token      *tokenizer.Token
+	Gate       string
+	Expression Expression
+	Shared     bool
+}
+
+func (s *UniversalStatement) Literal() string {
+	if s.Shared {
+		return fmt.Sprintf("%s %s shared", s.Gate, s.Expression.Literal())
+	}
+	return fmt.Sprintf("%s %s", s.Gate, s.Expression.Literal())
+}
+
+type ClassesStatement struct {
+	Token      *tokenizer.Token
+	Expression Expression
+}
+
+func (s *ClassesStatement) Literal() string {
+	return fmt.Sprintf("classes %s", s.Expression.Literal())
+}
+
+// Connective is a member of the set given to the complete statement, either
+// an operator written on its own or a formula whose identifiers are its
+// arguments in the order they first appear.
+type Connective struct {
+	Operator   string
+	Expression Expression
+}
+
+func (c *Connective) Literal() string {
+	if c.Expression != nil {
+		return c.Expression.Literal()
+	}
+	return c.Operator
+}
+
+type CompleteStatement struct {
+	Token       *tokenizer.Token
+	Connectives []*Connective
+}
+
+func (s *CompleteStatement) Literal() string {
+	result := ""
+	for i, connective := range s.Connectives {
+		if i > 0 {
+			result += ", "
+		}
+		result += connective.Literal()
+	}
+	return fmt.Sprintf("complete {%s}", result)
+}
+
+func expressionsLiteral(expressions []Expression) string {
+	result := ""
+	for i, expression := range expressions {
+		if i > 0 {
+			result += ", "
+		}
+		result += expression.Literal()
+	}
+	return result
+}
+
+func projectionLiteral(projection []*Identifier) string {
+	if len(projection) == 0 {
+		return ""
+	}
+	result := " over "
+	for i, ident := range projection {
+		if i > 0 {
+			result += ", "
+		}
+		result += ident.Literal()
+	}
+	return result
+}
+
 type PrefixExpression struct {
 	Token  *tokenizer.Token
 	Op     string
@@ -98,3 +443,24 @@ func (s *InfixExpression) Type() string {
 func (s *InfixExpression) Literal() string {
 	return fmt.Sprintf("(%s %s %s)", s.Left.Literal(), s.Op, s.Right.Literal())
 }
+
+// Clone returns a deep copy of the expression.
+func Clone(expression Expression) Expression {
+	switch expr := expression.(type) {
+	case *Identifier:
+		return &Identifier{Token: expr.Token, Value: expr.Value}
+	case *Boolean:
+		return &Boolean{Token: expr.Token, Value: expr.Value}
+	case *PrefixExpression:
+		return &PrefixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Right: Clone(expr.Right)}
+	case *InfixExpression:
+		return &InfixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Left: Clone(expr.Left), Right: Clone(expr.Right)}
+	case *NaryExpression:
+		operands := make([]Expression, 0, len(expr.Operands))
+		for _, operand := range expr.Operands {
+			operands = append(operands, Clone(operand))
+		}
+		return &NaryExpression{Op: expr.Op, Action: expr.Action, Operands: operands}
+	}
+	return expression
+}
@@ -0,0 +1,103 @@
+package ast
+
+import (
+	"slices"
+	"strings"
+)
+
+// NaryExpression is a conjunction or a disjunction of any number of
+// operands. NewNary keeps it flat, no operand has the same Action, and sorts
+// the operands by literal, so that two such expressions equal modulo
+// associativity and commutativity have the same literal. The binary form is
+// rebuilt by Binary, Literal prints it.
+type NaryExpression struct {
+	Op       string
+	Action   string
+	Operands []Expression
+}
+
+func (s *NaryExpression) Type() string {
+	return "nary"
+}
+
+func (s *NaryExpression) Literal() string {
+	result := s.Operands[0].Literal()
+	for _, operand := range s.Operands[1:] {
+		result = "(" + result + " " + s.Op + " " + operand.Literal() + ")"
+	}
+	return result
+}
+
+// NewNary joins the operands with the action, "and" or "or", merging operands
+// of the same action and sorting them. No operands give the neutral element
+// of the action and a single operand is returned as it is.
+func NewNary(action string, operands []Expression) Expression {
+	flat := make([]Expression, 0, len(operands))
+	for _, operand := range operands {
+		if nary, ok := operand.(*NaryExpression); ok && nary.Action == action {
+			flat = append(flat, nary.Operands...)
+			continue
+		}
+		flat = append(flat, operand)
+	}
+
+	switch len(flat) {
+	case 0:
+		return &Boolean{Value: action == "and"}
+	case 1:
+		return flat[0]
+	}
+
+	literals := make(map[Expression]string, len(flat))
+	for _, operand := range flat {
+		literals[operand] = operand.Literal()
+	}
+	slices.SortStableFunc(flat, func(a, b Expression) int {
+		return strings.Compare(literals[a], literals[b])
+	})
+
+	op := "+"
+	if action == "and" {
+		op = "*"
+	}
+	return &NaryExpression{Op: op, Action: action, Operands: flat}
+}
+
+// Flatten returns a copy of the expression with every chain of conjunctions
+// or disjunctions turned into a NaryExpression.
+func Flatten(expression Expression) Expression {
+	switch expr := expression.(type) {
+	case *PrefixExpression:
+		return &PrefixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Right: Flatten(expr.Right)}
+	case *InfixExpression:
+		if expr.Action == "and" || expr.Action == "or" {
+			return NewNary(expr.Action, []Expression{Flatten(expr.Left), Flatten(expr.Right)})
+		}
+		return &InfixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Left: Flatten(expr.Left), Right: Flatten(expr.Right)}
+	case *NaryExpression:
+		operands := make([]Expression, 0, len(expr.Operands))
+		for _, operand := range expr.Operands {
+			operands = append(operands, Flatten(operand))
+		}
+		return NewNary(expr.Action, operands)
+	}
+	return Clone(expression)
+}
+
+// Binary returns a copy of the expression with every NaryExpression rebuilt
+// as a left associated chain of InfixExpressions.
+func Binary(expression Expression) Expression {
+	switch expr := expression.(type) {
+	case *PrefixExpression:
+		return &PrefixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Right: Binary(expr.Right)}
+	case *InfixExpression:
+		return &InfixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Left: Binary(expr.Left), Right: Binary(expr.Right)}
+	case *NaryExpression:
+		result := Binary(expr.Operands[0])
+		for _, operand := range expr.Operands[1:] {
+			result = &InfixExpression{Op: expr.Op, Action: expr.Action, Left: result, Right: Binary(operand)}
+		}
+		return result
+	}
+	return Clone(expression)
+}
@@ -0,0 +1,335 @@
+package bdd
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Node is a reference to a node of a Manager. Two nodes of the same manager
+// represent the same function exactly when they are equal.
+type Node int
+
+const (
+	False Node = 0
+	True  Node = 1
+)
+
+// ErrNodeLimit is returned when building a diagram would exceed the node
+// limit of its manager.
+var ErrNodeLimit = errors.New("bdd: node limit exceeded")
+
+type node struct {
+	variable int
+	low      Node
+	high     Node
+}
+
+type operation struct {
+	f, g, h Node
+}
+
+// Manager owns the nodes of reduced ordered decision diagrams over a shared
+// set of variables. Variables are tested in the order of their levels, a
+// variable declared later is placed below all existing ones.
+type Manager struct {
+	nodes   []node
+	unique  map[node]Node
+	cache   map[operation]Node
+	names   []string
+	indices map[string]int
+	levels  []int
+	order   []int
+
+	// byVariable lists the nodes testing each variable, it lets a swap of
+	// two levels touch only the nodes on them.
+	byVariable [][]Node
+	// pinned holds intermediate results of a construction in progress so
+	// that dynamic reordering can account for them.
+	pinned []Node
+
+	// AutoReorder enables sifting while diagrams are built from expressions,
+	// it runs whenever the number of nodes has doubled since the last run.
+	AutoReorder      bool
+	reorderThreshold int
+
+	// MaxNodes limits the number of nodes the manager may allocate, zero
+	// means no limit.
+	MaxNodes int
+}
+
+func NewManager(names ...string) *Manager {
+	m := &Manager{
+		nodes:   []node{{variable: -1}, {variable: -1}},
+		unique:  make(map[node]Node),
+		cache:   make(map[operation]Node),
+		indices: make(map[string]int),
+	}
+	for _, name := range names {
+		m.declare(name)
+	}
+	return m
+}
+
+func (m *Manager) declare(name string) int {
+	if index, ok := m.indices[name]; ok {
+		return index
+	}
+	index := len(m.names)
+	m.names = append(m.names, name)
+	m.indices[name] = index
+	m.levels = append(m.levels, len(m.order))
+	m.order = append(m.order, index)
+	m.byVariable = append(m.byVariable, nil)
+	return index
+}
+
+// Var returns the diagram of a single variable, declaring it when needed.
+func (m *Manager) Var(name string) Node {
+	return m.makeNode(m.declare(name), False, True)
+}
+
+// Variables returns the variable names ordered by level.
+func (m *Manager) Variables() []string {
+	result := make([]string, 0, len(m.order))
+	for _, index := range m.order {
+		result = append(result, m.names[index])
+	}
+	return result
+}
+
+func (m *Manager) NumNodes() int {
+	return len(m.nodes)
+}
+
+func (m *Manager) makeNode(variable int, low Node, high Node) Node {
+	if low == high {
+		return low
+	}
+	key := node{variable: variable, low: low, high: high}
+	if existing, ok := m.unique[key]; ok {
+		return existing
+	}
+	if m.MaxNodes > 0 && len(m.nodes) >= m.MaxNodes {
+		panic(ErrNodeLimit)
+	}
+	id := Node(len(m.nodes))
+	m.nodes = append(m.nodes, key)
+	m.unique[key] = id
+	m.byVariable[variable] = append(m.byVariable[variable], id)
+	return id
+}
+
+// level returns the level of the variable tested by f, terminals lie below
+// every variable.
+func (m *Manager) level(f Node) int {
+	if f <= True {
+		return len(m.order)
+	}
+	return m.levels[m.nodes[f].variable]
+}
+
+// cofactors returns the low and high cofactors of f with respect to the
+// variable at the given level.
+func (m *Manager) cofactors(f Node, level int) (Node, Node) {
+	if m.level(f) != level {
+		return f, f
+	}
+	return m.nodes[f].low, m.nodes[f].high
+}
+
+// ITE computes if f then g else h, every other operation is expressed with it.
+func (m *Manager) ITE(f Node, g Node, h Node) Node {
+	switch {
+	case f == True:
+		return g
+	case f == False:
+		return h
+	case g == h:
+		return g
+	case g == True && h == False:
+		return f
+	}
+
+	key := operation{f, g, h}
+	if result, ok := m.cache[key]; ok {
+		return result
+	}
+
+	top := min(m.level(f), m.level(g), m.level(h))
+	f0, f1 := m.cofactors(f, top)
+	g0, g1 := m.cofactors(g, top)
+	h0, h1 := m.cofactors(h, top)
+	result := m.makeNode(m.order[top], m.ITE(f0, g0, h0), m.ITE(f1, g1, h1))
+
+	m.cache[key] = result
+	return result
+}
+
+func (m *Manager) Not(f Node) Node {
+	return m.ITE(f, False, True)
+}
+
+func (m *Manager) And(f Node, g Node) Node {
+	return m.ITE(f, g, False)
+}
+
+func (m *Manager) Or(f Node, g Node) Node {
+	return m.ITE(f, True, g)
+}
+
+func (m *Manager) Xor(f Node, g Node) Node {
+	return m.ITE(f, m.Not(g), g)
+}
+
+func (m *Manager) Implies(f Node, g Node) Node {
+	return m.ITE(f, g, True)
+}
+
+func (m *Manager) Equiv(f Node, g Node) Node {
+	return m.ITE(f, g, m.Not(g))
+}
+
+// Restrict fixes the named variable to value in f.
+func (m *Manager) Restrict(f Node, name string, value bool) Node {
+	index, ok := m.indices[name]
+	if !ok {
+		return f
+	}
+	return m.restrict(f, m.levels[index], value, make(map[Node]Node))
+}
+
+func (m *Manager) restrict(f Node, level int, value bool, visited map[Node]Node) Node {
+	if m.level(f) > level {
+		return f
+	}
+	if result, ok := visited[f]; ok {
+		return result
+	}
+
+	n := m.nodes[f]
+	var result Node
+	if m.level(f) == level {
+		if value {
+			result = n.high
+		} else {
+			result = n.low
+		}
+	} else {
+		result = m.makeNode(n.variable, m.restrict(n.low, level, value, visited), m.restrict(n.high, level, value, visited))
+	}
+
+	visited[f] = result
+	return result
+}
+
+// Compose substitutes g for the named variable in f.
+func (m *Manager) Compose(f Node, name string, g Node) Node {
+	return m.ITE(g, m.Restrict(f, name, true), m.Restrict(f, name, false))
+}
+
+// Exists quantifies the named variables away from f.
+func (m *Manager) Exists(f Node, names ...string) Node {
+	for _, name := range names {
+		f = m.Or(m.Restrict(f, name, false), m.Restrict(f, name, true))
+	}
+	return f
+}
+
+// SatCount returns the number of assignments to all variables of the manager
+// that satisfy f.
+func (m *Manager) SatCount(f Node) *big.Int {
+	counts := make(map[Node]*big.Int)
+	var count func(Node) *big.Int
+	count = func(f Node) *big.Int {
+		if f == False {
+			return big.NewInt(0)
+		}
+		if f == True {
+			return big.NewInt(1)
+		}
+		if result, ok := counts[f]; ok {
+			return result
+		}
+		n := m.nodes[f]
+		low := new(big.Int).Lsh(count(n.low), uint(m.level(n.low)-m.level(f)-1))
+		high := new(big.Int).Lsh(count(n.high), uint(m.level(n.high)-m.level(f)-1))
+		result := low.Add(low, high)
+		counts[f] = result
+		return result
+	}
+
+	result := new(big.Int).Set(count(f))
+	return result.Lsh(result, uint(m.level(f)))
+}
+
+// AnySat returns an assignment satisfying f to the variables on one of its
+// paths, other variables are left out. The second result is false when f is
+// unsatisfiable.
+func (m *Manager) AnySat(f Node) (map[string]bool, bool) {
+	if f == False {
+		return nil, false
+	}
+	assignment := make(map[string]bool)
+	for f != True {
+		n := m.nodes[f]
+		if n.low != False {
+			assignment[m.names[n.variable]] = false
+			f = n.low
+		} else {
+			assignment[m.names[n.variable]] = true
+			f = n.high
+		}
+	}
+	return assignment, true
+}
+
+// Size returns the number of nodes reachable from f, terminals included.
+func (m *Manager) Size(f Node) int {
+	return m.SharedSize(f)
+}
+
+// SharedSize returns the number of distinct nodes reachable from any of the
+// roots, terminals included.
+func (m *Manager) SharedSize(roots ...Node) int {
+	visited := make(map[Node]bool)
+	var visit func(Node)
+	visit = func(f Node) {
+		if visited[f] {
+			return
+		}
+		visited[f] = true
+		if f > True {
+			visit(m.nodes[f].low)
+			visit(m.nodes[f].high)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	return len(visited)
+}
+
+// Support returns the names of the variables f depends on ordered by level.
+func (m *Manager) Support(f Node) []string {
+	used := make(map[int]bool)
+	visited := make(map[Node]bool)
+	var visit func(Node)
+	visit = func(f Node) {
+		if f <= True || visited[f] {
+			return
+		}
+		visited[f] = true
+		used[m.nodes[f].variable] = true
+		visit(m.nodes[f].low)
+		visit(m.nodes[f].high)
+	}
+	visit(f)
+
+	result := make([]string, 0, len(used))
+	for _, index := range m.order {
+		if used[index] {
+			result = append(result, m.names[index])
+		}
+	}
+	return result
+}
@@ -0,0 +1,211 @@
+package bdd
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/parser"
+	"github.com/terawatthour/logix/tokenizer"
+	"math/big"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+var testVariables = []string{"a", "b", "c", "d", "e", "f"}
+
+func randomExpression(r *rand.Rand, depth int) ast.Expression {
+	if depth == 0 || r.Intn(4) == 0 {
+		if r.Intn(12) == 0 {
+			return &ast.Boolean{Value: r.Intn(2) == 0}
+		}
+		return &ast.Identifier{Value: testVariables[r.Intn(len(testVariables))]}
+	}
+	if r.Intn(4) == 0 {
+		return &ast.PrefixExpression{Op: "!", Right: randomExpression(r, depth-1)}
+	}
+	operators := []string{"+", "*", "->", "<->", "^"}
+	return &ast.InfixExpression{Op: operators[r.Intn(len(operators))], Left: randomExpression(r, depth-1), Right: randomExpression(r, depth-1)}
+}
+
+func evaluate(expression ast.Expression, assignment map[string]bool) bool {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return assignment[expr.Value]
+	case *ast.Boolean:
+		return expr.Value
+	case *ast.PrefixExpression:
+		return !evaluate(expr.Right, assignment)
+	case *ast.InfixExpression:
+		left, right := evaluate(expr.Left, assignment), evaluate(expr.Right, assignment)
+		switch expr.Op {
+		case "+":
+			return left || right
+		case "*":
+			return left && right
+		case "->":
+			return !left || right
+		case "<->":
+			return left == right
+		}
+		return left != right
+	}
+	panic("unreachable")
+}
+
+// assignments lists every assignment to the test variables.
+func assignments() []map[string]bool {
+	result := make([]map[string]bool, 0, 1<<len(testVariables))
+	for bits := 0; bits < 1<<len(testVariables); bits++ {
+		assignment := make(map[string]bool)
+		for i, name := range testVariables {
+			assignment[name] = bits&(1<<i) != 0
+		}
+		result = append(result, assignment)
+	}
+	return result
+}
+
+// follow walks the diagram along the assignment to a terminal.
+func follow(m *Manager, f Node, assignment map[string]bool) bool {
+	for f > True {
+		n := m.nodes[f]
+		if assignment[m.names[n.variable]] {
+			f = n.high
+		} else {
+			f = n.low
+		}
+	}
+	return f == True
+}
+
+func truthTable(m *Manager, f Node) []bool {
+	table := make([]bool, 0, 1<<len(testVariables))
+	for _, assignment := range assignments() {
+		table = append(table, follow(m, f, assignment))
+	}
+	return table
+}
+
+func expressionTable(expression ast.Expression) []bool {
+	table := make([]bool, 0, 1<<len(testVariables))
+	for _, assignment := range assignments() {
+		table = append(table, evaluate(expression, assignment))
+	}
+	return table
+}
+
+func countTrue(table []bool) *big.Int {
+	count := int64(0)
+	for _, value := range table {
+		if value {
+			count++
+		}
+	}
+	return big.NewInt(count)
+}
+
+func TestCanonicalForm(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	m := NewManager(testVariables...)
+	functions := make(map[string]Node)
+	for i := 0; i < 2000; i++ {
+		expression := randomExpression(r, 5)
+		f, err := m.FromExpression(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		table := expressionTable(expression)
+		if !slices.Equal(truthTable(m, f), table) {
+			t.Fatalf("the diagram of %s computes another function", expression.Literal())
+		}
+		key := fmt.Sprint(table)
+		if other, ok := functions[key]; ok && other != f {
+			t.Fatalf("%s gives node %d, an equivalent formula gave %d", expression.Literal(), f, other)
+		}
+		functions[key] = f
+	}
+}
+
+func TestEquivalentFormulasShareANode(t *testing.T) {
+	pairs := [][2]string{
+		{"!(a * b)", "!a + !b"},
+		{"a -> b", "!b -> !a"},
+		{"a ^ b", "(a + b) * !(a * b)"},
+		{"a <-> b", "!(a ^ b)"},
+		{"a + a * b", "a"},
+		{"a * !a", "0"},
+	}
+	for _, pair := range pairs {
+		m := NewManager()
+		left, _ := m.FromExpression(parse(t, pair[0]))
+		right, _ := m.FromExpression(parse(t, pair[1]))
+		if left != right {
+			t.Errorf("%s and %s have different nodes %d and %d", pair[0], pair[1], left, right)
+		}
+	}
+}
+
+func parse(t *testing.T, source string) ast.Expression {
+	t.Helper()
+	tok := tokenizer.NewTokenizer(source)
+	if err := tok.Tokenize(); err != nil {
+		t.Fatal(err)
+	}
+	expression, err := parser.NewParser(tok).ParseExpression()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expression
+}
+
+func TestRestrictAndCompose(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	m := NewManager(testVariables...)
+	for i := 0; i < 500; i++ {
+		expression := randomExpression(r, 5)
+		substitute := randomExpression(r, 3)
+		name := testVariables[r.Intn(len(testVariables))]
+		f, _ := m.FromExpression(expression)
+		g, _ := m.FromExpression(substitute)
+
+		for _, value := range []bool{false, true} {
+			restricted := m.Restrict(f, name, value)
+			for _, assignment := range assignments() {
+				fixed := with(assignment, name, value)
+				if follow(m, restricted, assignment) != evaluate(expression, fixed) {
+					t.Fatalf("%s restricted by %s = %t is wrong under %v", expression.Literal(), name, value, assignment)
+				}
+			}
+		}
+
+		composed := m.Compose(f, name, g)
+		for _, assignment := range assignments() {
+			replaced := with(assignment, name, evaluate(substitute, assignment))
+			if follow(m, composed, assignment) != evaluate(expression, replaced) {
+				t.Fatalf("%s with %s for %s is wrong under %v", expression.Literal(), substitute.Literal(), name, assignment)
+			}
+		}
+	}
+}
+
+// with returns a copy of the assignment with the name set to the value.
+func with(assignment map[string]bool, name string, value bool) map[string]bool {
+	result := make(map[string]bool, len(assignment))
+	for k, v := range assignment {
+		result[k] = v
+	}
+	result[name] = value
+	return result
+}
+
+func TestSatCount(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	m := NewManager(testVariables...)
+	for i := 0; i < 500; i++ {
+		expression := randomExpression(r, 5)
+		f, _ := m.FromExpression(expression)
+		if got, want := m.SatCount(f), countTrue(expressionTable(expression)); got.Cmp(want) != 0 {
+			t.Fatalf("%s: SatCount %s, want %s", expression.Literal(), got, want)
+		}
+	}
+}
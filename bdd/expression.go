@@ -0,0 +1,124 @@
+package bdd
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+)
+
+// FromExpression builds the diagram of an expression, identifiers not yet
+// known to the manager are declared in order of appearance.
+func (m *Manager) FromExpression(expression ast.Expression) (result Node, err error) {
+	pinned := len(m.pinned)
+	defer func() {
+		m.pinned = m.pinned[:pinned]
+		if r := recover(); r != nil {
+			if r != ErrNodeLimit {
+				panic(r)
+			}
+			err = ErrNodeLimit
+		}
+	}()
+
+	return m.fromExpression(expression)
+}
+
+func (m *Manager) fromExpression(expression ast.Expression) (Node, error) {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return m.Var(expr.Value), nil
+	case *ast.Boolean:
+		if expr.Value {
+			return True, nil
+		}
+		return False, nil
+	case *ast.PrefixExpression:
+		right, err := m.fromExpression(expr.Right)
+		if err != nil {
+			return False, err
+		}
+		if expr.Op != "!" {
+			return False, fmt.Errorf("bdd: unknown prefix operator %s", expr.Op)
+		}
+		return m.Not(right), nil
+	case *ast.InfixExpression:
+		left, err := m.fromExpression(expr.Left)
+		if err != nil {
+			return False, err
+		}
+		m.pinned = append(m.pinned, left)
+		right, err := m.fromExpression(expr.Right)
+		m.pinned = m.pinned[:len(m.pinned)-1]
+		if err != nil {
+			return False, err
+		}
+		m.maybeReorder(left, right)
+		switch expr.Op {
+		case "+", "|":
+			return m.Or(left, right), nil
+		case "*", "&":
+			return m.And(left, right), nil
+		case "->":
+			return m.Implies(left, right), nil
+		case "<->":
+			return m.Equiv(left, right), nil
+		case "^":
+			return m.Xor(left, right), nil
+		}
+		return False, fmt.Errorf("bdd: unknown infix operator %s", expr.Op)
+	}
+
+	return False, fmt.Errorf("bdd: unsupported expression %s", expression.Literal())
+}
+
+// ToExpression turns a diagram back into an expression by Shannon
+// decomposition on the variable of every node.
+func (m *Manager) ToExpression(f Node) ast.Expression {
+	expressions := make(map[Node]ast.Expression)
+	var convert func(Node) ast.Expression
+	convert = func(f Node) ast.Expression {
+		switch f {
+		case False:
+			return &ast.Boolean{Value: false}
+		case True:
+			return &ast.Boolean{Value: true}
+		}
+		if expression, ok := expressions[f]; ok {
+			return expression
+		}
+
+		n := m.nodes[f]
+		variable := &ast.Identifier{Value: m.names[n.variable]}
+		negated := &ast.PrefixExpression{Op: "!", Right: variable}
+
+		var expression ast.Expression
+		switch {
+		case n.low == False && n.high == True:
+			expression = variable
+		case n.low == True && n.high == False:
+			expression = negated
+		case n.low == False:
+			expression = conjunction(variable, convert(n.high))
+		case n.high == False:
+			expression = conjunction(negated, convert(n.low))
+		case n.high == True:
+			expression = disjunction(variable, convert(n.low))
+		case n.low == True:
+			expression = disjunction(negated, convert(n.high))
+		default:
+			expression = disjunction(conjunction(variable, convert(n.high)), conjunction(negated, convert(n.low)))
+		}
+
+		expressions[f] = expression
+		return expression
+	}
+
+	return convert(f)
+}
+
+func conjunction(left ast.Expression, right ast.Expression) ast.Expression {
+	return &ast.InfixExpression{Op: "*", Action: "and", Left: left, Right: right}
+}
+
+func disjunction(left ast.Expression, right ast.Expression) ast.Expression {
+	return &ast.InfixExpression{Op: "+", Action: "or", Left: left, Right: right}
+}
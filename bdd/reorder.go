@@ -0,0 +1,209 @@
+package bdd
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// maxSiftGrowth stops moving a variable in one direction once the diagram
+// has grown by this factor over the best size seen for it.
+const maxSiftGrowth = 1.2
+
+// initialReorderThreshold is the number of nodes at which automatic
+// reordering first runs.
+const initialReorderThreshold = 4096
+
+// swapLevels exchanges the variables at level and level+1 in place. Every
+// node keeps its identity and keeps representing the same function, so
+// references held by callers and the computed cache stay valid.
+func (m *Manager) swapLevels(level int) {
+	x, y := m.order[level], m.order[level+1]
+
+	nodes := m.byVariable[x]
+	m.byVariable[x] = nil
+	for _, id := range nodes {
+		n := m.nodes[id]
+		lowTestsY := n.low > True && m.nodes[n.low].variable == y
+		highTestsY := n.high > True && m.nodes[n.high].variable == y
+		if !lowTestsY && !highTestsY {
+			m.byVariable[x] = append(m.byVariable[x], id)
+			continue
+		}
+
+		f00, f01 := n.low, n.low
+		if lowTestsY {
+			f00, f01 = m.nodes[n.low].low, m.nodes[n.low].high
+		}
+		f10, f11 := n.high, n.high
+		if highTestsY {
+			f10, f11 = m.nodes[n.high].low, m.nodes[n.high].high
+		}
+
+		delete(m.unique, n)
+		rewritten := node{variable: y, low: m.makeNode(x, f00, f10), high: m.makeNode(x, f01, f11)}
+		m.nodes[id] = rewritten
+		m.unique[rewritten] = id
+		m.byVariable[y] = append(m.byVariable[y], id)
+	}
+
+	m.order[level], m.order[level+1] = y, x
+	m.levels[x], m.levels[y] = level+1, level
+}
+
+// Sift reorders the variables with Rudell's sifting algorithm, minimising the
+// number of nodes reachable from the roots. Each variable in turn is moved
+// through all levels and left where the diagrams were smallest.
+func (m *Manager) Sift(roots ...Node) {
+	limit := m.MaxNodes
+	m.MaxNodes = 0
+	defer func() { m.MaxNodes = limit }()
+
+	variables := slices.Clone(m.order)
+	sort.SliceStable(variables, func(i, j int) bool {
+		return len(m.byVariable[variables[i]]) > len(m.byVariable[variables[j]])
+	})
+
+	for _, variable := range variables {
+		m.siftVariable(variable, roots)
+	}
+}
+
+func (m *Manager) siftVariable(variable int, roots []Node) {
+	best := m.SharedSize(roots...)
+	bestLevel := m.levels[variable]
+	last := len(m.order) - 1
+
+	for m.levels[variable] < last {
+		m.swapLevels(m.levels[variable])
+		size := m.SharedSize(roots...)
+		if size < best {
+			best, bestLevel = size, m.levels[variable]
+		} else if float64(size) > maxSiftGrowth*float64(best) {
+			break
+		}
+	}
+	for m.levels[variable] > 0 {
+		m.swapLevels(m.levels[variable] - 1)
+		size := m.SharedSize(roots...)
+		if size < best {
+			best, bestLevel = size, m.levels[variable]
+		} else if float64(size) > maxSiftGrowth*float64(best) {
+			break
+		}
+	}
+	for m.levels[variable] < bestLevel {
+		m.swapLevels(m.levels[variable])
+	}
+	for m.levels[variable] > bestLevel {
+		m.swapLevels(m.levels[variable] - 1)
+	}
+}
+
+// maybeReorder sifts when automatic reordering is enabled and the manager has
+// grown past its threshold, the pinned nodes and the given ones are kept
+// small.
+func (m *Manager) maybeReorder(nodes ...Node) {
+	if !m.AutoReorder {
+		return
+	}
+	if m.reorderThreshold == 0 {
+		m.reorderThreshold = initialReorderThreshold
+	}
+	if len(m.unique) < m.reorderThreshold {
+		return
+	}
+
+	m.Sift(append(slices.Clone(m.pinned), nodes...)...)
+	m.reorderThreshold = 2 * len(m.unique)
+}
+
+// DFSOrder orders the identifiers of an expression by a depth-first walk
+// that enters the operand with the larger fan-in first, so that variables
+// feeding the same deep subexpression end up close together.
+func DFSOrder(expression ast.Expression) []string {
+	fanIn := make(map[ast.Expression]int)
+	var measure func(ast.Expression) int
+	measure = func(expression ast.Expression) int {
+		size := 1
+		switch expr := expression.(type) {
+		case *ast.PrefixExpression:
+			size += measure(expr.Right)
+		case *ast.InfixExpression:
+			size += measure(expr.Left) + measure(expr.Right)
+		}
+		fanIn[expression] = size
+		return size
+	}
+	measure(expression)
+
+	order := make([]string, 0)
+	var visit func(ast.Expression)
+	visit = func(expression ast.Expression) {
+		switch expr := expression.(type) {
+		case *ast.Identifier:
+			if !slices.Contains(order, expr.Value) {
+				order = append(order, expr.Value)
+			}
+		case *ast.PrefixExpression:
+			visit(expr.Right)
+		case *ast.InfixExpression:
+			if fanIn[expr.Right] > fanIn[expr.Left] {
+				visit(expr.Right)
+				visit(expr.Left)
+			} else {
+				visit(expr.Left)
+				visit(expr.Right)
+			}
+		}
+	}
+	visit(expression)
+
+	return order
+}
+
+var indexedName = regexp.MustCompile(`^(.*?)(\d+)$`)
+
+// InterleavedOrder orders identifiers that share a stem and differ by a
+// numeric suffix, such as the bits a0, a1, ... and b0, b1, ... of compared
+// words, as a0, b0, a1, b1, .... Identifiers without a suffix keep their
+// DFSOrder position ahead of the interleaved ones.
+func InterleavedOrder(expression ast.Expression) []string {
+	type indexed struct {
+		name  string
+		stem  int
+		index int
+	}
+
+	stems := make([]string, 0)
+	plain := make([]string, 0)
+	words := make([]indexed, 0)
+	for _, name := range DFSOrder(expression) {
+		match := indexedName.FindStringSubmatch(name)
+		if match == nil {
+			plain = append(plain, name)
+			continue
+		}
+		index, _ := strconv.Atoi(match[2])
+		stem := slices.Index(stems, match[1])
+		if stem == -1 {
+			stem = len(stems)
+			stems = append(stems, match[1])
+		}
+		words = append(words, indexed{name: name, stem: stem, index: index})
+	}
+
+	sort.SliceStable(words, func(i, j int) bool {
+		if words[i].index != words[j].index {
+			return words[i].index < words[j].index
+		}
+		return words[i].stem < words[j].stem
+	})
+
+	for _, word := range words {
+		plain = append(plain, word.name)
+	}
+	return plain
+}
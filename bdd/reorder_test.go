@@ -0,0 +1,132 @@
+package bdd
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// checkOrdered fails when a node has a child on its own level or above it.
+func checkOrdered(t *testing.T, m *Manager, f Node) {
+	t.Helper()
+	if f <= True {
+		return
+	}
+	n := m.nodes[f]
+	if m.level(n.low) <= m.level(f) || m.level(n.high) <= m.level(f) {
+		t.Fatalf("node %d is not above its children", f)
+	}
+	checkOrdered(t, m, n.low)
+	checkOrdered(t, m, n.high)
+}
+
+func TestSiftKeepsFunctions(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 200; i++ {
+		m := NewManager(testVariables...)
+		roots := make([]Node, 0, 3)
+		tables := make([][]bool, 0, 3)
+		for len(roots) < 3 {
+			expression := randomExpression(r, 6)
+			f, _ := m.FromExpression(expression)
+			roots = append(roots, f)
+			tables = append(tables, expressionTable(expression))
+		}
+
+		size := m.SharedSize(roots...)
+		for _, variable := range slices.Clone(m.order) {
+			m.siftVariable(variable, roots)
+			sifted := m.SharedSize(roots...)
+			if sifted > size {
+				t.Fatalf("sifting %s grew the diagrams from %d to %d nodes", m.names[variable], size, sifted)
+			}
+			size = sifted
+		}
+
+		for k, f := range roots {
+			checkOrdered(t, m, f)
+			if !slices.Equal(truthTable(m, f), tables[k]) {
+				t.Fatalf("sifting changed the function of root %d", k)
+			}
+			if got, want := m.SatCount(f), countTrue(tables[k]); got.Cmp(want) != 0 {
+				t.Fatalf("SatCount of root %d is %s after sifting, want %s", k, got, want)
+			}
+		}
+	}
+}
+
+func TestAutoReorderKeepsFunctions(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	m := NewManager(testVariables...)
+	m.AutoReorder = true
+	m.reorderThreshold = 8
+	for i := 0; i < 200; i++ {
+		expression := randomExpression(r, 6)
+		f, err := m.FromExpression(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(truthTable(m, f), expressionTable(expression)) {
+			t.Fatalf("the diagram of %s built with reordering computes another function", expression.Literal())
+		}
+	}
+}
+
+func TestStaticOrders(t *testing.T) {
+	source := "(a0 <-> b0) * (a1 <-> b1) * (a2 <-> b2) * (a3 <-> b3)"
+	expression := parse(t, source)
+
+	interleaved := InterleavedOrder(expression)
+	want := []string{"a0", "b0", "a1", "b1", "a2", "b2", "a3", "b3"}
+	if !slices.Equal(interleaved, want) {
+		t.Errorf("interleaved order %v, want %v", interleaved, want)
+	}
+
+	separated := NewManager("a0", "a1", "a2", "a3", "b0", "b1", "b2", "b3")
+	f, _ := separated.FromExpression(expression)
+	m := NewManager(interleaved...)
+	g, _ := m.FromExpression(expression)
+	if m.Size(g) >= separated.Size(f) {
+		t.Errorf("%s has %d nodes in the interleaved order and %d with the words apart", source, m.Size(g), separated.Size(f))
+	}
+	if m.SatCount(g).Cmp(separated.SatCount(f)) != 0 {
+		t.Errorf("the orders count %s and %s models", m.SatCount(g), separated.SatCount(f))
+	}
+
+	r := rand.New(rand.NewSource(6))
+	for i := 0; i < 200; i++ {
+		expression := randomExpression(r, 6)
+		for _, order := range [][]string{DFSOrder(expression), InterleavedOrder(expression)} {
+			if !isPermutation(order, identifiers(expression)) {
+				t.Fatalf("%v does not order the identifiers of %s", order, expression.Literal())
+			}
+			m := NewManager(order...)
+			f, _ := m.FromExpression(expression)
+			if !slices.Equal(truthTable(m, f), expressionTable(expression)) {
+				t.Fatalf("the diagram of %s in the order %v computes another function", expression.Literal(), order)
+			}
+		}
+	}
+}
+
+func identifiers(expression ast.Expression) []string {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return []string{expr.Value}
+	case *ast.PrefixExpression:
+		return identifiers(expr.Right)
+	case *ast.InfixExpression:
+		return append(identifiers(expr.Left), identifiers(expr.Right)...)
+	}
+	return nil
+}
+
+// isPermutation reports whether order lists every distinct name once.
+func isPermutation(order []string, names []string) bool {
+	slices.Sort(names)
+	names = slices.Compact(names)
+	sorted := slices.Clone(order)
+	slices.Sort(sorted)
+	return slices.Equal(sorted, names)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// UnsoundRewriteError reports a rewrite of a simplification rule that does
+// not preserve the meaning of the subterm it replaced.
+type UnsoundRewriteError struct {
+	Rule       string
+	Input      ast.Expression
+	Output     ast.Expression
+	Difference map[string]bool
+}
+
+func (e *UnsoundRewriteError) Error() string {
+	idents := getIdentifiersOfAll([]ast.Expression{e.Input, e.Output})
+	return fmt.Sprintf("unsound rewrite by the %s rule: %s => %s, they differ at %s", e.Rule, e.Input.Literal(), e.Output.Literal(), plainAssignment(idents, e.Difference))
+}
+
+// plainAssignment formats an assignment like formatAssignment but without
+// colours, for use in error messages.
+func plainAssignment(idents []string, assignment map[string]bool) string {
+	values := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		value := 0
+		if assignment[ident] {
+			value = 1
+		}
+		values = append(values, fmt.Sprintf("%s = %d", ident, value))
+	}
+	return strings.Join(values, ", ")
+}
+
+// SimplifyChecked simplifies like Simplify, but tests every rewrite for
+// equivalence with the subterm it replaces. Unsound rewrites are not applied,
+// the first one is returned next to the result as an *UnsoundRewriteError.
+func (e *Evaluator) SimplifyChecked(ctx context.Context, expression ast.Expression, options SimplifyOptions) (ast.Expression, StopReason, error) {
+	e.checked, e.unsound = true, nil
+	defer func() { e.checked = false }()
+
+	result, stop := e.Simplify(ctx, expression, options)
+	if e.unsound != nil {
+		return result, stop, e.unsound
+	}
+	return result, stop, nil
+}
+
+// sound reports whether a rewrite made by the rule preserves equivalence,
+// recording the first unsound one.
+func (e *Evaluator) sound(rule SimplificationRule, input ast.Expression, output ast.Expression) bool {
+	input, output = ast.Binary(input), ast.Binary(output)
+	equivalent, difference := Equivalent(input, output)
+	if !equivalent && e.unsound == nil {
+		e.unsound = &UnsoundRewriteError{Rule: rule.Name, Input: input, Output: output, Difference: difference}
+	}
+	return equivalent
+}
+
+// defaultSimplifyOptions bound the simplify statement, so that rules growing
+// the formula cannot hang the REPL. Traces keep a copy of the formula per
+// step and are bounded tighter.
+var (
+	defaultSimplifyOptions = SimplifyOptions{MaxSteps: 10000, MaxSize: 10000}
+	explainSimplifyOptions = SimplifyOptions{MaxSteps: 1000, MaxSize: 1000}
+)
+
+func (e *Evaluator) formatSimplification(stmt *ast.SimplifyStatement) string {
+	e.checked, e.unsound = stmt.Checked, nil
+	defer func() { e.checked = false }()
+
+	// an interrupt stops the simplification instead of the REPL
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var result string
+	if stmt.Explain != "" {
+		result = formatTrace(e.Explain(ctx, stmt.Expression, explainSimplifyOptions), stmt.Explain)
+	} else {
+		simplified, stop := e.Simplify(ctx, stmt.Expression, defaultSimplifyOptions)
+		result = simplified.Literal()
+		if stop != Fixpoint {
+			result += fmt.Sprintf("\nstopped by the %s, this is the smallest formula reached", stop)
+		}
+	}
+	if e.unsound != nil {
+		result += fmt.Sprintf("\n\033[31m%s\033[0m", e.unsound.Error())
+	}
+	return result
+}
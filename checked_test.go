@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"github.com/terawatthour/logix/ast"
+	"testing"
+)
+
+// fuzzExpression decodes a formula over a, b and c from the fuzzer's bytes,
+// every byte picks the kind of the next node in preorder.
+func fuzzExpression(data []byte) ast.Expression {
+	operators := []string{"+", "*", "->", "<->", "^"}
+	actions := []string{"or", "and", "->", "<->", "xor"}
+	leaves := []ast.Expression{
+		&ast.Identifier{Value: "a"},
+		&ast.Identifier{Value: "b"},
+		&ast.Identifier{Value: "c"},
+		&ast.Boolean{Value: false},
+		&ast.Boolean{Value: true},
+	}
+
+	var build func(depth int) ast.Expression
+	build = func(depth int) ast.Expression {
+		var b byte
+		if len(data) > 0 {
+			b, data = data[0], data[1:]
+		}
+		kind := int(b % 9)
+		switch {
+		case depth >= 6 || kind < 3:
+			return ast.Clone(leaves[int(b/9)%len(leaves)])
+		case kind == 3:
+			return &ast.PrefixExpression{Op: "!", Right: build(depth + 1)}
+		}
+		op := (kind - 4) % len(operators)
+		return &ast.InfixExpression{Op: operators[op], Action: actions[op], Left: build(depth + 1), Right: build(depth + 1)}
+	}
+	return build(0)
+}
+
+func FuzzSimplifyChecked(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{3, 5, 0, 9})
+	f.Add([]byte{4, 4, 0, 9, 0})
+	f.Add([]byte{4, 0, 4, 9, 0})
+	f.Add([]byte{5, 0, 0})
+	f.Add([]byte{3, 4, 3, 0, 9, 7, 18, 27})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		expression := fuzzExpression(data)
+		input := ast.Clone(expression)
+
+		result, _, err := NewEvaluator().SimplifyChecked(context.Background(), expression, SimplifyOptions{MaxSteps: 10000})
+		if err != nil {
+			t.Fatalf("simplifying %s: %s", input.Literal(), err)
+		}
+		if equivalent, _ := Equivalent(input, result); !equivalent {
+			t.Fatalf("%s simplified to the inequivalent %s", input.Literal(), result.Literal())
+		}
+	})
+}
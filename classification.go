@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/sat"
+)
+
+// enumerationLimit is the largest number of identifiers for which formulas are
+// classified by walking the truth table instead of calling the SAT solver.
+const enumerationLimit = 12
+
+type Classification int
+
+const (
+	Tautology Classification = iota
+	Contradiction
+	Contingent
+)
+
+func (c Classification) String() string {
+	switch c {
+	case Tautology:
+		return "tautology"
+	case Contradiction:
+		return "contradiction"
+	}
+	return "contingent"
+}
+
+// ClassificationResult holds the class of a formula with a witness for each
+// truth value the formula can take, the missing side is nil.
+type ClassificationResult struct {
+	Class      Classification
+	Satisfying map[string]bool
+	Falsifying map[string]bool
+}
+
+func Classify(expression ast.Expression) ClassificationResult {
+	var result ClassificationResult
+	if len(getAllIdentifiers(expression, nil)) <= enumerationLimit {
+		result.Satisfying, result.Falsifying = enumerateWitnesses(expression)
+	} else {
+		result.Satisfying, result.Falsifying = solveWitnesses(expression)
+	}
+
+	switch {
+	case result.Falsifying == nil:
+		result.Class = Tautology
+	case result.Satisfying == nil:
+		result.Class = Contradiction
+	default:
+		result.Class = Contingent
+	}
+	return result
+}
+
+func IsValid(expression ast.Expression) bool {
+	return Classify(expression).Class == Tautology
+}
+
+func IsUnsatisfiable(expression ast.Expression) bool {
+	return Classify(expression).Class == Contradiction
+}
+
+// enumerateWitnesses walks the truth table until it has found an assignment
+// for each truth value or run out of rows.
+func enumerateWitnesses(expression ast.Expression) (map[string]bool, map[string]bool) {
+	idents := getAllIdentifiers(expression, nil)
+	var satisfying, falsifying map[string]bool
+	for _, permutation := range generateBinaryPermutations(len(idents)) {
+		values := make(map[string]bool)
+		for i, v := range permutation {
+			values[idents[i]] = v
+		}
+		if evaluateExpression(values, expression) {
+			if satisfying == nil {
+				satisfying = values
+			}
+		} else if falsifying == nil {
+			falsifying = values
+		}
+		if satisfying != nil && falsifying != nil {
+			break
+		}
+	}
+	return satisfying, falsifying
+}
+
+// solveWitnesses encodes the expression once and asks the solver for an
+// assignment with the literal of the whole formula assumed true, then for
+// one with it assumed false.
+func solveWitnesses(expression ast.Expression) (map[string]bool, map[string]bool) {
+	solver := sat.NewSolver()
+	encoding := newCNFEncoding(solver, getAllIdentifiers(expression, nil))
+	root := encoding.encode(expression)
+
+	var satisfying, falsifying map[string]bool
+	if solver.Solve(root) {
+		satisfying = encoding.assignment()
+	}
+	if solver.Solve(-root) {
+		falsifying = encoding.assignment()
+	}
+	return satisfying, falsifying
+}
+
+func formatValidity(expression ast.Expression) string {
+	result := Classify(expression)
+	if result.Class == Tautology {
+		return bold("valid")
+	}
+	idents := getAllIdentifiers(expression, nil)
+	return fmt.Sprintf("%s countermodel: %s", bold("not valid,"), formatAssignment(idents, result.Falsifying))
+}
+
+func formatUnsatisfiability(expression ast.Expression) string {
+	result := Classify(expression)
+	if result.Class == Contradiction {
+		return bold("unsatisfiable")
+	}
+	idents := getAllIdentifiers(expression, nil)
+	return fmt.Sprintf("%s model: %s", bold("satisfiable,"), formatAssignment(idents, result.Satisfying))
+}
+
+func formatClassification(expression ast.Expression) string {
+	result := Classify(expression)
+	idents := getAllIdentifiers(expression, nil)
+	output := bold(result.Class.String())
+	if result.Satisfying != nil {
+		output += fmt.Sprintf("\ntrue under:  %s", formatAssignment(idents, result.Satisfying))
+	}
+	if result.Falsifying != nil {
+		output += fmt.Sprintf("\nfalse under: %s", formatAssignment(idents, result.Falsifying))
+	}
+	return output
+}
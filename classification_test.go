@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// checkWitnesses fails when a witness is missing for a truth value the
+// expression takes, or does not give the expression that value.
+func checkWitnesses(t *testing.T, expression ast.Expression, satisfying, falsifying map[string]bool, satisfiable, falsifiable bool) {
+	t.Helper()
+	if (satisfying != nil) != satisfiable || (falsifying != nil) != falsifiable {
+		t.Fatalf("%s: witnesses %v and %v, want satisfiable %t and falsifiable %t", expression.Literal(), satisfying, falsifying, satisfiable, falsifiable)
+	}
+	if satisfying != nil && !evaluateExpression(satisfying, expression) {
+		t.Errorf("%s: false under the satisfying %v", expression.Literal(), satisfying)
+	}
+	if falsifying != nil && evaluateExpression(falsifying, expression) {
+		t.Errorf("%s: true under the falsifying %v", expression.Literal(), falsifying)
+	}
+}
+
+func TestClassifyWitnesses(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		data := make([]byte, r.Intn(24))
+		r.Read(data)
+		expression := fuzzExpression(data)
+
+		satisfying, falsifying := enumerateWitnesses(expression)
+		satisfiable, falsifiable := satisfying != nil, falsifying != nil
+		checkWitnesses(t, expression, satisfying, falsifying, satisfiable, falsifiable)
+
+		satisfying, falsifying = solveWitnesses(expression)
+		checkWitnesses(t, expression, satisfying, falsifying, satisfiable, falsifiable)
+	}
+}
+
+func TestClassifyBySolver(t *testing.T) {
+	idents := make([]string, enumerationLimit+2)
+	for i := range idents {
+		idents[i] = fmt.Sprintf("x%d", i)
+	}
+	chain := make([]string, 0, len(idents))
+	for i := 1; i < len(idents); i++ {
+		chain = append(chain, fmt.Sprintf("(%s -> %s)", idents[i-1], idents[i]))
+	}
+	implication := strings.Join(chain, " * ")
+	first, last := idents[0], idents[len(idents)-1]
+
+	cases := map[string]Classification{
+		fmt.Sprintf("%s -> (%s -> %s)", implication, first, last): Tautology,
+		fmt.Sprintf("%s * %s * !%s", implication, first, last):    Contradiction,
+		implication: Contingent,
+	}
+	for source, want := range cases {
+		expression := parseRuleSide(source)
+		result := Classify(expression)
+		if result.Class != want {
+			t.Errorf("%s: classified as %s, want %s", source, result.Class, want)
+		}
+		checkWitnesses(t, expression, result.Satisfying, result.Falsifying, want != Contradiction, want != Tautology)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/sat"
+)
+
+// cnfEncoding translates expressions into clauses of a sat.Solver using the
+// Tseitin transformation. Identifiers are given the first variables in order,
+// auxiliary variables for subexpressions come after them.
+type cnfEncoding struct {
+	solver      *sat.Solver
+	identifiers []string
+	variables   map[string]int
+	shared      map[string]int
+	truth       int
+	clauses     [][]int
+}
+
+func newCNFEncoding(solver *sat.Solver, identifiers []string) *cnfEncoding {
+	c := &cnfEncoding{
+		solver:      solver,
+		identifiers: identifiers,
+		variables:   make(map[string]int),
+		shared:      make(map[string]int),
+	}
+	for _, ident := range identifiers {
+		c.variable(ident)
+	}
+	return c
+}
+
+func (c *cnfEncoding) variable(ident string) int {
+	if v, ok := c.variables[ident]; ok {
+		return v
+	}
+	v := c.solver.NewVar()
+	c.variables[ident] = v
+	return v
+}
+
+func (c *cnfEncoding) addClause(lits ...int) {
+	c.clauses = append(c.clauses, lits)
+	c.solver.AddClause(lits...)
+}
+
+// assert adds clauses forcing the expression to be true.
+func (c *cnfEncoding) assert(expression ast.Expression) {
+	c.addClause(c.encode(expression))
+}
+
+// encode returns a literal equivalent to the expression.
+func (c *cnfEncoding) encode(expression ast.Expression) int {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return c.variable(expr.Value)
+	case *ast.Boolean:
+		if c.truth == 0 {
+			c.truth = c.solver.NewVar()
+			c.addClause(c.truth)
+		}
+		if expr.Value {
+			return c.truth
+		}
+		return -c.truth
+	}
+
+	if lit, ok := c.shared[expression.Literal()]; ok {
+		return lit
+	}
+
+	var lit int
+	switch expr := expression.(type) {
+	case *ast.PrefixExpression:
+		lit = -c.encode(expr.Right)
+	case *ast.InfixExpression:
+		left := c.encode(expr.Left)
+		right := c.encode(expr.Right)
+		lit = c.solver.NewVar()
+		switch expr.Op {
+		case "+", "|":
+			c.addClause(-lit, left, right)
+			c.addClause(lit, -left)
+			c.addClause(lit, -right)
+		case "*", "&":
+			c.addClause(lit, -left, -right)
+			c.addClause(-lit, left)
+			c.addClause(-lit, right)
+		case "->":
+			c.addClause(-lit, -left, right)
+			c.addClause(lit, left)
+			c.addClause(lit, -right)
+		case "<->":
+			c.addClause(-lit, -left, right)
+			c.addClause(-lit, left, -right)
+			c.addClause(lit, left, right)
+			c.addClause(lit, -left, -right)
+		case "^":
+			c.addClause(-lit, left, right)
+			c.addClause(-lit, -left, -right)
+			c.addClause(lit, -left, right)
+			c.addClause(lit, left, -right)
+		default:
+			panic("unreachable")
+		}
+	default:
+		panic("unreachable")
+	}
+
+	c.shared[expression.Literal()] = lit
+	return lit
+}
+
+// assignment reads the values of the identifiers from the solver's last model.
+func (c *cnfEncoding) assignment() map[string]bool {
+	result := make(map[string]bool)
+	for _, ident := range c.identifiers {
+		result[ident] = c.solver.Value(c.variables[ident])
+	}
+	return result
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/sat"
+	"strings"
+)
+
+// UnsatCore returns the indices of a minimal unsatisfiable subset of the
+// formulas, the second result is false when all formulas can be true
+// together. Every formula is guarded by an assumption literal, the core
+// reported by the solver is then shrunk by deleting one formula at a time.
+func UnsatCore(formulas []ast.Expression) ([]int, bool) {
+	solver := sat.NewSolver()
+	encoding := newCNFEncoding(solver, getIdentifiersOfAll(formulas))
+
+	selectors := make([]int, len(formulas))
+	owner := make(map[int]int)
+	for i, formula := range formulas {
+		selectors[i] = solver.NewVar()
+		owner[selectors[i]] = i
+		encoding.addClause(-selectors[i], encoding.encode(formula))
+	}
+
+	if solver.Solve(selectors...) {
+		return nil, false
+	}
+
+	core := solverCore(solver, owner)
+	for i := 0; i < len(core); {
+		candidate := append(append([]int{}, core[:i]...), core[i+1:]...)
+		assumptions := make([]int, 0, len(candidate))
+		for _, index := range candidate {
+			assumptions = append(assumptions, selectors[index])
+		}
+		if solver.Solve(assumptions...) {
+			i++
+			continue
+		}
+		core = solverCore(solver, owner)
+		// the refined core is a subset of the candidate, so every formula
+		// before position i was already shown to be necessary
+		i = min(i, len(core))
+	}
+
+	return core, true
+}
+
+// solverCore maps the failed assumptions of the last call to Solve back to
+// formula indices in input order.
+func solverCore(solver *sat.Solver, owner map[int]int) []int {
+	failed := make(map[int]bool)
+	for _, lit := range solver.Conflict() {
+		if index, ok := owner[abs(lit)]; ok {
+			failed[index] = true
+		}
+	}
+	core := make([]int, 0, len(failed))
+	for index := 0; index < len(owner); index++ {
+		if failed[index] {
+			core = append(core, index)
+		}
+	}
+	return core
+}
+
+func formatUnsatCore(formulas []ast.Expression, spans []ast.Span, sources []string) string {
+	core, unsatisfiable := UnsatCore(formulas)
+	if !unsatisfiable {
+		return bold("consistent") + ", there is no unsatisfiable core"
+	}
+
+	width := 0
+	for _, index := range core {
+		width = max(width, len(sources[index]))
+	}
+	lines := []string{bold(fmt.Sprintf("unsatisfiable core of %d out of %d formulas:", len(core), len(formulas)))}
+	for _, index := range core {
+		span := spans[index]
+		lines = append(lines, fmt.Sprintf("  #%d  %-*s  columns %d-%d", index+1, width, sources[index], span.Start+1, span.End))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/sat"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CountModels returns the exact number of assignments over
+// getAllIdentifiers(expression) that satisfy the expression.
+func CountModels(expression ast.Expression) *big.Int {
+	return CountProjectedModels(expression, getAllIdentifiers(expression, nil))
+}
+
+// CountProjectedModels returns the number of assignments to the given
+// identifiers which can be extended to a model of the expression.
+// Identifiers that do not occur in the expression are counted as free.
+// Decision diagrams are used for medium-size formulas, larger ones go to the
+// component caching counter.
+func CountProjectedModels(expression ast.Expression, projection []string) *big.Int {
+	if len(getAllIdentifiers(expression, projection)) <= bddIdentifierLimit {
+		if count, ok := countModelsWithBDD(expression, projection); ok {
+			return count
+		}
+	}
+	return countModelsByComponents(expression, projection)
+}
+
+func countModelsByComponents(expression ast.Expression, projection []string) *big.Int {
+	solver := sat.NewSolver()
+	encoding := newCNFEncoding(solver, projection)
+	encoding.assert(expression)
+
+	clauses := encoding.clauses
+
+	counter := &modelCounter{
+		projected: make(map[int]bool),
+		cache:     make(map[string]*big.Int),
+	}
+	for _, ident := range projection {
+		counter.projected[encoding.variables[ident]] = true
+	}
+
+	occurring := countProjectedVariables(counter.projected, clauses)
+	result := counter.count(clauses)
+	return result.Lsh(result, uint(len(counter.projected)-occurring))
+}
+
+// modelCounter is an exact projected model counter over clauses, it splits
+// the clause set into variable-disjoint components and caches their counts.
+type modelCounter struct {
+	projected map[int]bool
+	cache     map[string]*big.Int
+}
+
+// count returns the number of assignments to the projected variables
+// occurring in clauses which extend to a model of the clauses.
+func (m *modelCounter) count(clauses [][]int) *big.Int {
+	before := countProjectedVariables(m.projected, clauses)
+
+	remaining, assigned, ok := propagateUnits(clauses)
+	if !ok {
+		return big.NewInt(0)
+	}
+
+	fixed := 0
+	for v := range assigned {
+		if m.projected[v] {
+			fixed++
+		}
+	}
+	free := before - fixed - countProjectedVariables(m.projected, remaining)
+
+	result := big.NewInt(1)
+	for _, component := range splitComponents(remaining) {
+		result.Mul(result, m.countComponent(component))
+		if result.Sign() == 0 {
+			return result
+		}
+	}
+	return result.Lsh(result, uint(free))
+}
+
+func (m *modelCounter) countComponent(clauses [][]int) *big.Int {
+	key := componentKey(clauses)
+	if cached, ok := m.cache[key]; ok {
+		return new(big.Int).Set(cached)
+	}
+
+	branch := 0
+	occurrences := make(map[int]int)
+	for _, clause := range clauses {
+		for _, lit := range clause {
+			v := abs(lit)
+			if !m.projected[v] {
+				continue
+			}
+			occurrences[v]++
+			if branch == 0 || occurrences[v] > occurrences[branch] || (occurrences[v] == occurrences[branch] && v < branch) {
+				branch = v
+			}
+		}
+	}
+
+	var result *big.Int
+	if branch == 0 {
+		result = big.NewInt(0)
+		if satisfiableClauses(clauses) {
+			result.SetInt64(1)
+		}
+	} else {
+		result = m.count(append([][]int{{branch}}, clauses...))
+		result.Add(result, m.count(append([][]int{{-branch}}, clauses...)))
+	}
+
+	m.cache[key] = new(big.Int).Set(result)
+	return result
+}
+
+// propagateUnits applies unit propagation to the clauses, returning the
+// clauses left unsatisfied and the variables assigned along the way.
+func propagateUnits(clauses [][]int) ([][]int, map[int]bool, bool) {
+	assigned := make(map[int]bool)
+	for {
+		unit := 0
+		for _, clause := range clauses {
+			if len(clause) == 0 {
+				return nil, nil, false
+			}
+			if len(clause) == 1 {
+				unit = clause[0]
+				break
+			}
+		}
+		if unit == 0 {
+			return clauses, assigned, true
+		}
+
+		assigned[abs(unit)] = unit > 0
+		next := make([][]int, 0, len(clauses))
+		for _, clause := range clauses {
+			if slices.Contains(clause, unit) {
+				continue
+			}
+			if slices.Contains(clause, -unit) {
+				reduced := make([]int, 0, len(clause)-1)
+				for _, lit := range clause {
+					if lit != -unit {
+						reduced = append(reduced, lit)
+					}
+				}
+				clause = reduced
+			}
+			next = append(next, clause)
+		}
+		clauses = next
+	}
+}
+
+// splitComponents partitions clauses into groups that share no variables.
+func splitComponents(clauses [][]int) [][][]int {
+	parent := make(map[int]int)
+	var find func(int) int
+	find = func(v int) int {
+		if p, ok := parent[v]; ok && p != v {
+			parent[v] = find(p)
+			return parent[v]
+		}
+		parent[v] = v
+		return v
+	}
+
+	for _, clause := range clauses {
+		first := find(abs(clause[0]))
+		for _, lit := range clause[1:] {
+			parent[find(abs(lit))] = first
+		}
+	}
+
+	order := make([]int, 0)
+	groups := make(map[int][][]int)
+	for _, clause := range clauses {
+		root := find(abs(clause[0]))
+		if _, ok := groups[root]; !ok {
+			order = append(order, root)
+		}
+		groups[root] = append(groups[root], clause)
+	}
+
+	components := make([][][]int, 0, len(order))
+	for _, root := range order {
+		components = append(components, groups[root])
+	}
+	return components
+}
+
+func componentKey(clauses [][]int) string {
+	keys := make([]string, 0, len(clauses))
+	for _, clause := range clauses {
+		sorted := slices.Clone(clause)
+		slices.Sort(sorted)
+		parts := make([]string, 0, len(sorted))
+		for _, lit := range sorted {
+			parts = append(parts, strconv.Itoa(lit))
+		}
+		keys = append(keys, strings.Join(parts, " "))
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+func countProjectedVariables(projected map[int]bool, clauses [][]int) int {
+	seen := make(map[int]bool)
+	for _, clause := range clauses {
+		for _, lit := range clause {
+			if v := abs(lit); projected[v] {
+				seen[v] = true
+			}
+		}
+	}
+	return len(seen)
+}
+
+func satisfiableClauses(clauses [][]int) bool {
+	solver := sat.NewSolver()
+	for _, clause := range clauses {
+		solver.AddClause(clause...)
+	}
+	return solver.Solve()
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func formatCount(expression ast.Expression, projection []string) string {
+	identifiers := projection
+	if len(identifiers) == 0 {
+		identifiers = getAllIdentifiers(expression, nil)
+	}
+	count := CountProjectedModels(expression, identifiers)
+	total := new(big.Int).Lsh(big.NewInt(1), uint(len(identifiers)))
+	result := fmt.Sprintf("%s of %s assignments", bold(count.String()), total.String())
+	if len(identifiers) > 0 {
+		result += " over " + strings.Join(identifiers, ", ")
+	}
+	return result
+}
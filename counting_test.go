@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// truthTableCount counts the assignments to the projection which extend to a
+// model of the expression by enumerating its truth table.
+func truthTableCount(t *testing.T, expression ast.Expression, projection []string) *big.Int {
+	t.Helper()
+	idents := getAllIdentifiers(expression, append([]string{}, projection...))
+	vector, err := truthVector(expression, idents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extendable := make(map[int]bool)
+	for index, value := range vector {
+		if value {
+			extendable[index&(1<<len(projection)-1)] = true
+		}
+	}
+	return big.NewInt(int64(len(extendable)))
+}
+
+func TestCountProjectedModels(t *testing.T) {
+	expressions := []ast.Expression{
+		parseRuleSide("1"),
+		parseRuleSide("0"),
+		parseRuleSide("a * !a"),
+		parseRuleSide("a + !a"),
+		parseRuleSide("(a + b) * (c + d) * (e ^ f)"),
+		parseRuleSide("(a -> b) * (b -> c) * (c -> d) * !(a <-> d)"),
+	}
+	r := rand.New(rand.NewSource(1))
+	for len(expressions) < 300 {
+		data := make([]byte, 4+r.Intn(24))
+		r.Read(data)
+		expressions = append(expressions, fuzzExpression(data))
+	}
+
+	for _, expression := range expressions {
+		idents := getAllIdentifiers(expression, nil)
+		projections := [][]string{idents, {}, append(append([]string{}, idents...), "z")}
+		if len(idents) > 1 {
+			projections = append(projections, idents[:1], idents[1:])
+		}
+		for _, projection := range projections {
+			want := truthTableCount(t, expression, projection)
+			bdd, ok := countModelsWithBDD(expression, projection)
+			if !ok {
+				t.Fatalf("%s over %v: the diagram could not be built", expression.Literal(), projection)
+			}
+			components := countModelsByComponents(expression, projection)
+			if bdd.Cmp(want) != 0 || components.Cmp(want) != 0 {
+				t.Errorf("%s over %v: diagrams count %s, components %s, truth table %s", expression.Literal(), projection, bdd, components, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/terawatthour/logix/deduction"
+	"os"
+	"strings"
+)
+
+// formatDeduction checks the natural deduction proof in a file and prints it
+// in Fitch layout with the errors under the lines they concern.
+func formatDeduction(path string) string {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err.Error()
+	}
+	proof, err := deduction.Parse(string(source))
+	if err != nil {
+		return err.Error()
+	}
+
+	faults := make(map[int][]string)
+	errs := proof.Check()
+	for _, err := range errs {
+		var lineError *deduction.Error
+		if errors.As(err, &lineError) {
+			faults[lineError.Line] = append(faults[lineError.Line], lineError.Message)
+		}
+	}
+
+	width := 0
+	for _, line := range proof.Lines {
+		width = max(width, 2*line.Depth+len(line.Formula.Literal()))
+	}
+
+	lines := make([]string, 0, len(proof.Lines)+1)
+	for _, line := range proof.Lines {
+		citations := make([]string, 0, len(line.Citations))
+		for _, citation := range line.Citations {
+			citations = append(citations, citation.String())
+		}
+		formula := strings.Repeat("│ ", line.Depth) + line.Formula.Literal()
+		mark := "\033[32m✓\033[0m"
+		if len(faults[line.Number]) > 0 {
+			mark = "\033[31m✗\033[0m"
+		}
+		lines = append(lines, strings.TrimRight(fmt.Sprintf("%s %3d  %s%s  %s %s", mark, line.Number, formula, strings.Repeat(" ", width-2*line.Depth-len(line.Formula.Literal())), line.Rule, strings.Join(citations, ", ")), " "))
+		for _, message := range faults[line.Number] {
+			lines = append(lines, fmt.Sprintf("        \033[31m%s\033[0m", message))
+		}
+	}
+
+	if len(errs) > 0 {
+		lines = append(lines, fmt.Sprintf("%s %d error(s)", bold("invalid proof,"), len(errs)))
+	} else {
+		premises := make([]string, 0)
+		for _, premise := range proof.Premises() {
+			premises = append(premises, premise.Literal())
+		}
+		lines = append(lines, fmt.Sprintf("%s %s |- %s", bold("valid proof of"), strings.Join(premises, ", "), proof.Conclusion().Literal()))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,496 @@
+package deduction
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"slices"
+	"strings"
+)
+
+// Error reports a step of a proof that does not follow from its citations.
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// subproof is the scope of an assumption, it spans the lines from start to
+// end. The whole proof is the subproof without a parent and assumption.
+type subproof struct {
+	start  int
+	end    int
+	parent *subproof
+}
+
+func (s *subproof) encloses(other *subproof) bool {
+	for ; other != nil; other = other.parent {
+		if other == s {
+			return true
+		}
+	}
+	return false
+}
+
+type checker struct {
+	proof  *Proof
+	scopes []*subproof
+	starts map[int]*subproof
+}
+
+// Check validates every line of the proof against its rule. It returns one
+// error per faulty line in line order, and nil when the proof is correct.
+func (p *Proof) Check() []error {
+	c := &checker{proof: p, starts: make(map[int]*subproof)}
+	errs := c.structure()
+
+	for _, line := range p.Lines {
+		if err := c.check(line); err != nil {
+			errs = append(errs, &Error{Line: line.Number, Message: err.Error()})
+		}
+	}
+
+	if last := p.Lines[len(p.Lines)-1]; last.Depth > 0 {
+		errs = append(errs, &Error{Line: last.Number, Message: "the proof ends inside a subproof"})
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(errs, func(a error, b error) int {
+		return a.(*Error).Line - b.(*Error).Line
+	})
+	return errs
+}
+
+// structure assigns every line to its subproof from the depth of the lines.
+// An assumption always opens a new subproof, so two subproofs at the same
+// depth may follow each other directly.
+func (c *checker) structure() []error {
+	errs := make([]error, 0)
+	root := &subproof{start: 1}
+	current := root
+	depth := 0
+	premises := true
+
+	for _, line := range c.proof.Lines {
+		if line.Rule == Premise {
+			if line.Depth > 0 {
+				errs = append(errs, &Error{Line: line.Number, Message: "premises cannot appear inside a subproof"})
+			} else if !premises {
+				errs = append(errs, &Error{Line: line.Number, Message: "premises must precede all other lines"})
+			}
+		} else {
+			premises = false
+		}
+
+		target := line.Depth
+		if line.Rule == Assumption {
+			if target == 0 {
+				errs = append(errs, &Error{Line: line.Number, Message: "an assumption must open a subproof, indent it with |"})
+			} else if target > depth+1 {
+				errs = append(errs, &Error{Line: line.Number, Message: fmt.Sprintf("subproof nested %d levels deep opened at depth %d", target, depth)})
+			}
+			for depth >= target && depth > 0 {
+				current, depth = current.parent, depth-1
+			}
+		} else {
+			if target > depth {
+				errs = append(errs, &Error{Line: line.Number, Message: "only an assumption can open a subproof"})
+			}
+			for depth > target {
+				current, depth = current.parent, depth-1
+			}
+		}
+		for depth < target {
+			current = &subproof{start: line.Number, parent: current}
+			c.starts[line.Number] = current
+			depth++
+		}
+
+		c.scopes = append(c.scopes, current)
+		for scope := current; scope != nil; scope = scope.parent {
+			scope.end = line.Number
+		}
+	}
+	return errs
+}
+
+func (c *checker) line(number int) *Line {
+	return c.proof.Lines[number-1]
+}
+
+// cited resolves a citation of a single line made at line at.
+func (c *checker) cited(at int, citation Citation) (*Line, error) {
+	if citation.Range {
+		return nil, fmt.Errorf("expected a line, got the range %s", citation)
+	}
+	if citation.From < 1 || citation.From >= at {
+		return nil, fmt.Errorf("cited line %d is not above this line", citation.From)
+	}
+	scope := c.scopes[citation.From-1]
+	if !scope.encloses(c.scopes[at-1]) {
+		return nil, fmt.Errorf("cited line %d lies in the closed subproof %d-%d", citation.From, scope.start, scope.end)
+	}
+	return c.line(citation.From), nil
+}
+
+// citedSubproof resolves a citation of a closed subproof made at line at,
+// returning its assumption and its last line.
+func (c *checker) citedSubproof(at int, citation Citation) (*Line, *Line, error) {
+	if !citation.Range {
+		return nil, nil, fmt.Errorf("expected a subproof, got the single line %s", citation)
+	}
+	if citation.From < 1 || citation.From > citation.To {
+		return nil, nil, fmt.Errorf("%s is not a range of lines", citation)
+	}
+	if citation.To >= at {
+		return nil, nil, fmt.Errorf("cited subproof %s is not above this line", citation)
+	}
+	scope, ok := c.starts[citation.From]
+	if !ok || c.line(citation.From).Rule != Assumption {
+		return nil, nil, fmt.Errorf("no subproof starts at line %d", citation.From)
+	}
+	if scope.end != citation.To {
+		return nil, nil, fmt.Errorf("the subproof starting at line %d ends at line %d, not %d", citation.From, scope.end, citation.To)
+	}
+	if !scope.parent.encloses(c.scopes[at-1]) {
+		return nil, nil, fmt.Errorf("cited subproof %s lies in a closed subproof", citation)
+	}
+	last := c.line(citation.To)
+	if c.scopes[citation.To-1] != scope {
+		return nil, nil, fmt.Errorf("cited subproof %s ends inside a nested subproof", citation)
+	}
+	return c.line(citation.From), last, nil
+}
+
+// citations resolves the citations of a line, which must be the given number
+// of single lines followed or preceded by the given number of subproofs.
+func (c *checker) citations(line *Line, lines int, subproofs int) ([]*Line, [][2]*Line, error) {
+	singles := make([]*Line, 0, lines)
+	ranges := make([][2]*Line, 0, subproofs)
+	gotLines, gotSubproofs := 0, 0
+	for _, citation := range line.Citations {
+		if citation.Range {
+			gotSubproofs++
+		} else {
+			gotLines++
+		}
+	}
+	if gotLines != lines || gotSubproofs != subproofs {
+		return nil, nil, fmt.Errorf("%s cites %s, got %s", line.Rule, describe(lines, subproofs), describe(gotLines, gotSubproofs))
+	}
+
+	for _, citation := range line.Citations {
+		if citation.Range {
+			assumption, last, err := c.citedSubproof(line.Number, citation)
+			if err != nil {
+				return nil, nil, err
+			}
+			ranges = append(ranges, [2]*Line{assumption, last})
+		} else {
+			cited, err := c.cited(line.Number, citation)
+			if err != nil {
+				return nil, nil, err
+			}
+			singles = append(singles, cited)
+		}
+	}
+	return singles, ranges, nil
+}
+
+func describe(lines int, subproofs int) string {
+	parts := make([]string, 0, 2)
+	switch lines {
+	case 0:
+	case 1:
+		parts = append(parts, "one line")
+	default:
+		parts = append(parts, fmt.Sprintf("%d lines", lines))
+	}
+	switch subproofs {
+	case 0:
+	case 1:
+		parts = append(parts, "one subproof")
+	default:
+		parts = append(parts, fmt.Sprintf("%d subproofs", subproofs))
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, " and ")
+}
+
+func (c *checker) check(line *Line) error {
+	switch line.Rule {
+	case Premise, Assumption:
+		_, _, err := c.citations(line, 0, 0)
+		return err
+
+	case Reiteration:
+		lines, _, err := c.citations(line, 1, 0)
+		if err != nil {
+			return err
+		}
+		return expect(line, lines[0].Formula)
+
+	case AndIntro:
+		lines, _, err := c.citations(line, 2, 0)
+		if err != nil {
+			return err
+		}
+		if infix, ok := binary(line.Formula, "*"); ok && equal(infix.Left, lines[1].Formula) && equal(infix.Right, lines[0].Formula) {
+			return nil
+		}
+		return expect(line, conjunction(lines[0].Formula, lines[1].Formula))
+
+	case AndElim:
+		lines, _, err := c.citations(line, 1, 0)
+		if err != nil {
+			return err
+		}
+		infix, ok := binary(lines[0].Formula, "*")
+		if !ok {
+			return notA(lines[0], "conjunction")
+		}
+		if equal(line.Formula, infix.Left) || equal(line.Formula, infix.Right) {
+			return nil
+		}
+		return fmt.Errorf("%s is neither conjunct of %s", line.Formula.Literal(), infix.Literal())
+
+	case OrIntro:
+		lines, _, err := c.citations(line, 1, 0)
+		if err != nil {
+			return err
+		}
+		infix, ok := binary(line.Formula, "+")
+		if !ok {
+			return fmt.Errorf("%s is not a disjunction", line.Formula.Literal())
+		}
+		if equal(infix.Left, lines[0].Formula) || equal(infix.Right, lines[0].Formula) {
+			return nil
+		}
+		return fmt.Errorf("neither disjunct of %s is %s from line %d", infix.Literal(), lines[0].Formula.Literal(), lines[0].Number)
+
+	case OrElim:
+		lines, subproofs, err := c.citations(line, 1, 2)
+		if err != nil {
+			return err
+		}
+		infix, ok := binary(lines[0].Formula, "+")
+		if !ok {
+			return notA(lines[0], "disjunction")
+		}
+		first, second := subproofs[0], subproofs[1]
+		if !equal(first[0].Formula, infix.Left) || !equal(second[0].Formula, infix.Right) {
+			first, second = second, first
+		}
+		if !equal(first[0].Formula, infix.Left) {
+			return fmt.Errorf("no cited subproof assumes the left disjunct %s", infix.Left.Literal())
+		}
+		if !equal(second[0].Formula, infix.Right) {
+			return fmt.Errorf("no cited subproof assumes the right disjunct %s", infix.Right.Literal())
+		}
+		for _, subproof := range [][2]*Line{first, second} {
+			if !equal(subproof[1].Formula, line.Formula) {
+				return fmt.Errorf("the subproof %d-%d concludes %s, not %s", subproof[0].Number, subproof[1].Number, subproof[1].Formula.Literal(), line.Formula.Literal())
+			}
+		}
+		return nil
+
+	case ImpliesIntro:
+		_, subproofs, err := c.citations(line, 0, 1)
+		if err != nil {
+			return err
+		}
+		return expect(line, implication(subproofs[0][0].Formula, subproofs[0][1].Formula))
+
+	case ImpliesElim:
+		lines, _, err := c.citations(line, 2, 0)
+		if err != nil {
+			return err
+		}
+		for _, order := range [][2]*Line{{lines[0], lines[1]}, {lines[1], lines[0]}} {
+			if infix, ok := binary(order[0].Formula, "->"); ok && equal(infix.Left, order[1].Formula) {
+				return expect(line, infix.Right)
+			}
+		}
+		return fmt.Errorf("neither line %d nor line %d is an implication whose antecedent is the other line", lines[0].Number, lines[1].Number)
+
+	case IffIntro:
+		_, subproofs, err := c.citations(line, 0, 2)
+		if err != nil {
+			return err
+		}
+		first, second := subproofs[0], subproofs[1]
+		if !equal(first[0].Formula, second[1].Formula) || !equal(first[1].Formula, second[0].Formula) {
+			return fmt.Errorf("the subproofs %d-%d and %d-%d do not prove converse implications", first[0].Number, first[1].Number, second[0].Number, second[1].Number)
+		}
+		if infix, ok := binary(line.Formula, "<->"); ok && equal(infix.Left, second[0].Formula) && equal(infix.Right, first[0].Formula) {
+			return nil
+		}
+		return expect(line, biconditional(first[0].Formula, first[1].Formula))
+
+	case IffElim:
+		lines, _, err := c.citations(line, 2, 0)
+		if err != nil {
+			return err
+		}
+		for _, order := range [][2]*Line{{lines[0], lines[1]}, {lines[1], lines[0]}} {
+			if infix, ok := binary(order[0].Formula, "<->"); ok {
+				if equal(infix.Left, order[1].Formula) {
+					return expect(line, infix.Right)
+				}
+				if equal(infix.Right, order[1].Formula) {
+					return expect(line, infix.Left)
+				}
+			}
+		}
+		return fmt.Errorf("neither line %d nor line %d is a biconditional with the other line as one side", lines[0].Number, lines[1].Number)
+
+	case NotIntro:
+		_, subproofs, err := c.citations(line, 0, 1)
+		if err != nil {
+			return err
+		}
+		if !falsum(subproofs[0][1].Formula) {
+			return fmt.Errorf("the subproof %d-%d ends with %s, not with 0", subproofs[0][0].Number, subproofs[0][1].Number, subproofs[0][1].Formula.Literal())
+		}
+		return expect(line, negation(subproofs[0][0].Formula))
+
+	case NotElim:
+		lines, _, err := c.citations(line, 2, 0)
+		if err != nil {
+			return err
+		}
+		if !contradictory(lines[0].Formula, lines[1].Formula) && !contradictory(lines[1].Formula, lines[0].Formula) {
+			return fmt.Errorf("lines %d and %d do not contradict each other", lines[0].Number, lines[1].Number)
+		}
+		return expect(line, &ast.Boolean{Value: false})
+
+	case FalsumElim:
+		lines, _, err := c.citations(line, 1, 0)
+		if err != nil {
+			return err
+		}
+		if !falsum(lines[0].Formula) {
+			return fmt.Errorf("cited line %d is %s, not 0", lines[0].Number, lines[0].Formula.Literal())
+		}
+		return nil
+
+	case DoubleNegElim:
+		lines, _, err := c.citations(line, 1, 0)
+		if err != nil {
+			return err
+		}
+		if inner, ok := negated(lines[0].Formula); ok {
+			if formula, ok := negated(inner); ok {
+				return expect(line, formula)
+			}
+		}
+		return notA(lines[0], "double negation")
+
+	case RAA:
+		_, subproofs, err := c.citations(line, 0, 1)
+		if err != nil {
+			return err
+		}
+		assumption, last := subproofs[0][0], subproofs[0][1]
+		if !falsum(last.Formula) {
+			return fmt.Errorf("the subproof %d-%d ends with %s, not with 0", assumption.Number, last.Number, last.Formula.Literal())
+		}
+		formula, ok := negated(assumption.Formula)
+		if !ok {
+			return fmt.Errorf("the subproof %d-%d assumes %s, which is not a negation", assumption.Number, last.Number, assumption.Formula.Literal())
+		}
+		return expect(line, formula)
+	}
+
+	return fmt.Errorf("unknown rule %s", line.Rule)
+}
+
+func expect(line *Line, formula ast.Expression) error {
+	if equal(line.Formula, formula) {
+		return nil
+	}
+	return fmt.Errorf("%s gives %s, not %s", line.Rule, formula.Literal(), line.Formula.Literal())
+}
+
+func notA(line *Line, kind string) error {
+	return fmt.Errorf("cited line %d is %s, not a %s", line.Number, line.Formula.Literal(), kind)
+}
+
+// equal compares formulas structurally, treating the alternative spellings
+// of an operator as the same operator.
+func equal(a ast.Expression, b ast.Expression) bool {
+	switch left := a.(type) {
+	case *ast.Identifier:
+		right, ok := b.(*ast.Identifier)
+		return ok && left.Value == right.Value
+	case *ast.Boolean:
+		right, ok := b.(*ast.Boolean)
+		return ok && left.Value == right.Value
+	case *ast.PrefixExpression:
+		right, ok := b.(*ast.PrefixExpression)
+		return ok && left.Op == right.Op && equal(left.Right, right.Right)
+	case *ast.InfixExpression:
+		right, ok := b.(*ast.InfixExpression)
+		return ok && operator(left.Op) == operator(right.Op) && equal(left.Left, right.Left) && equal(left.Right, right.Right)
+	}
+	return false
+}
+
+func operator(op string) string {
+	switch op {
+	case "&":
+		return "*"
+	case "|":
+		return "+"
+	}
+	return op
+}
+
+func binary(expression ast.Expression, op string) (*ast.InfixExpression, bool) {
+	infix, ok := expression.(*ast.InfixExpression)
+	if !ok || operator(infix.Op) != op {
+		return nil, false
+	}
+	return infix, true
+}
+
+func negated(expression ast.Expression) (ast.Expression, bool) {
+	prefix, ok := expression.(*ast.PrefixExpression)
+	if !ok || prefix.Op != "!" {
+		return nil, false
+	}
+	return prefix.Right, true
+}
+
+func falsum(expression ast.Expression) bool {
+	boolean, ok := expression.(*ast.Boolean)
+	return ok && !boolean.Value
+}
+
+// contradictory reports whether b is the negation of a.
+func contradictory(a ast.Expression, b ast.Expression) bool {
+	inner, ok := negated(b)
+	return ok && equal(a, inner)
+}
+
+func conjunction(left ast.Expression, right ast.Expression) ast.Expression {
+	return &ast.InfixExpression{Op: "*", Action: "and", Left: left, Right: right}
+}
+
+func implication(left ast.Expression, right ast.Expression) ast.Expression {
+	return &ast.InfixExpression{Op: "->", Action: "->", Left: left, Right: right}
+}
+
+func biconditional(left ast.Expression, right ast.Expression) ast.Expression {
+	return &ast.InfixExpression{Op: "<->", Action: "<->", Left: left, Right: right}
+}
+
+func negation(right ast.Expression) ast.Expression {
+	return &ast.PrefixExpression{Op: "!", Right: right}
+}
@@ -0,0 +1,249 @@
+package deduction
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/parser"
+	"github.com/terawatthour/logix/tokenizer"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rule is the canonical name of an inference rule of the Fitch system.
+type Rule string
+
+const (
+	Premise       Rule = "premise"
+	Assumption    Rule = "assume"
+	Reiteration   Rule = "R"
+	AndIntro      Rule = "∧I"
+	AndElim       Rule = "∧E"
+	OrIntro       Rule = "∨I"
+	OrElim        Rule = "∨E"
+	ImpliesIntro  Rule = "→I"
+	ImpliesElim   Rule = "→E"
+	IffIntro      Rule = "↔I"
+	IffElim       Rule = "↔E"
+	NotIntro      Rule = "¬I"
+	NotElim       Rule = "¬E"
+	FalsumElim    Rule = "⊥E"
+	DoubleNegElim Rule = "¬¬E"
+	RAA           Rule = "RAA"
+)
+
+// Citation refers to a single line, or with Range set to the subproof from
+// line From to line To.
+type Citation struct {
+	From  int
+	To    int
+	Range bool
+}
+
+func (c Citation) String() string {
+	if c.Range {
+		return fmt.Sprintf("%d-%d", c.From, c.To)
+	}
+	return strconv.Itoa(c.From)
+}
+
+// Line is a numbered step of a proof. Depth counts the subproofs the line is
+// nested in, written as vertical bars in front of the formula.
+type Line struct {
+	Number    int
+	Depth     int
+	Formula   ast.Expression
+	Rule      Rule
+	Citations []Citation
+}
+
+// Proof is a Fitch-style natural deduction proof, falsum is written as 0.
+type Proof struct {
+	Lines []*Line
+}
+
+// Premises returns the formulas of the premise lines.
+func (p *Proof) Premises() []ast.Expression {
+	premises := make([]ast.Expression, 0)
+	for _, line := range p.Lines {
+		if line.Rule == Premise {
+			premises = append(premises, line.Formula)
+		}
+	}
+	return premises
+}
+
+// Conclusion returns the formula of the last line, or nil for an empty proof.
+func (p *Proof) Conclusion() ast.Expression {
+	if len(p.Lines) == 0 {
+		return nil
+	}
+	return p.Lines[len(p.Lines)-1].Formula
+}
+
+var (
+	numberedLine = regexp.MustCompile(`^(\d+)[.:)]?\s+((?:\|\s*)*)(.*)$`)
+	separator    = regexp.MustCompile(`^[\s|]*-{2,}\s*$`)
+	citation     = `\d+(?:\s*-\s*\d+)?`
+	justified    = regexp.MustCompile(`^(.+?)\s+(\S+)(?:\s+(` + citation + `(?:\s*,\s*` + citation + `)*))?\s*$`)
+)
+
+// Parse reads a proof with one numbered line per step:
+//
+//	1  a -> b          premise
+//	2  | a             assume
+//	3  | b             ->E 1, 2
+//	4  a -> b          ->I 2-3
+//
+// Lines count up from 1. Blank lines, lines starting with # and separators
+// made of dashes are ignored. Rules may be spelled with the connectives of
+// the expression syntax, ->E for →E or *I for ∧I.
+func Parse(source string) (*Proof, error) {
+	proof := &Proof{Lines: make([]*Line, 0)}
+	errs := make([]error, 0)
+	expected := 1
+
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for row := 1; scanner.Scan(); row++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || separator.MatchString(text) {
+			continue
+		}
+
+		line, err := parseLine(text, expected)
+		if line != nil {
+			expected = line.Number + 1
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("deduction: line %d of the file: %w", row, err))
+			continue
+		}
+		proof.Lines = append(proof.Lines, line)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(proof.Lines) == 0 {
+		return nil, errors.New("deduction: the proof has no lines")
+	}
+	return proof, nil
+}
+
+func parseLine(text string, expected int) (*Line, error) {
+	match := numberedLine.FindStringSubmatch(text)
+	if match == nil {
+		return nil, errors.New("expected a line number")
+	}
+	number, _ := strconv.Atoi(match[1])
+	if number != expected {
+		return &Line{Number: number}, fmt.Errorf("expected line number %d, got %d", expected, number)
+	}
+
+	line := &Line{Number: number, Depth: strings.Count(match[2], "|")}
+	body := justified.FindStringSubmatch(match[3])
+	if body == nil {
+		return line, errors.New("expected a formula followed by a rule")
+	}
+
+	rule, ok := parseRule(body[2])
+	if !ok {
+		return line, fmt.Errorf("unknown rule %s", body[2])
+	}
+
+	formula, err := parseFormula(body[1])
+	if err != nil {
+		return line, err
+	}
+
+	citations := make([]Citation, 0)
+	if body[3] != "" {
+		for _, field := range strings.Split(body[3], ",") {
+			citations = append(citations, parseCitation(field))
+		}
+	}
+
+	line.Formula, line.Rule, line.Citations = formula, rule, citations
+	return line, nil
+}
+
+func parseFormula(text string) (ast.Expression, error) {
+	tok := tokenizer.NewTokenizer(text)
+	if err := tok.Tokenize(); err != nil {
+		return nil, fmt.Errorf("formula %s: %w", text, err)
+	}
+	formula, err := parser.NewParser(tok).ParseExpression()
+	if err != nil {
+		var parsingError *parser.ParsingError
+		if errors.As(err, &parsingError) {
+			return nil, fmt.Errorf("formula %s: %s", text, strings.Join(parsingError.Errors, ", "))
+		}
+		return nil, err
+	}
+	return formula, nil
+}
+
+func parseCitation(field string) Citation {
+	from, to, isRange := strings.Cut(field, "-")
+	first, _ := strconv.Atoi(strings.TrimSpace(from))
+	if !isRange {
+		return Citation{From: first, To: first}
+	}
+	last, _ := strconv.Atoi(strings.TrimSpace(to))
+	return Citation{From: first, To: last, Range: true}
+}
+
+var namedRules = map[string]Rule{
+	"premise":    Premise,
+	"pr":         Premise,
+	"assume":     Assumption,
+	"assumption": Assumption,
+	"hyp":        Assumption,
+	"hypothesis": Assumption,
+	"r":          Reiteration,
+	"reit":       Reiteration,
+	"raa":        RAA,
+	"ip":         RAA,
+	"pbc":        RAA,
+	"x":          FalsumElim,
+	"efq":        FalsumElim,
+	"dne":        DoubleNegElim,
+}
+
+var connectives = map[string]string{
+	"∧": "∧", "&": "∧", "*": "∧", "and": "∧",
+	"∨": "∨", "|": "∨", "+": "∨", "or": "∨",
+	"→": "→", "->": "→", "imp": "→",
+	"↔": "↔", "<->": "↔", "iff": "↔",
+	"¬": "¬", "!": "¬", "~": "¬", "not": "¬",
+	"¬¬": "¬¬", "!!": "¬¬", "~~": "¬¬",
+	"⊥": "⊥", "bot": "⊥",
+}
+
+// parseRule recognises a rule name, either a named rule or a connective
+// followed by I or E.
+func parseRule(name string) (Rule, bool) {
+	if rule, ok := namedRules[strings.ToLower(name)]; ok {
+		return rule, true
+	}
+
+	runes := []rune(name)
+	if len(runes) < 2 {
+		return "", false
+	}
+	kind := unicode.ToUpper(runes[len(runes)-1])
+	connective, ok := connectives[strings.ToLower(string(runes[:len(runes)-1]))]
+	if !ok || (kind != 'I' && kind != 'E') {
+		return "", false
+	}
+
+	rule := Rule(connective + string(kind))
+	switch rule {
+	case AndIntro, AndElim, OrIntro, OrElim, ImpliesIntro, ImpliesElim, IffIntro, IffElim, NotIntro, NotElim, FalsumElim, DoubleNegElim:
+		return rule, true
+	}
+	return "", false
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// law is the set of rules a derivation step names, every rule of the
+// evaluator whose name or alias matches.
+type law struct {
+	name  string
+	rules []func(ast.Expression) (bool, ast.Expression)
+}
+
+// lawKey drops case, spaces and punctuation, so that "De Morgan",
+// "de-morgan" and "De Morgan's" name the same law.
+func lawKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// lookupLaw collects the rules of the evaluator named by the law, through
+// their names or aliases, a plural names the same law.
+func (e *Evaluator) lookupLaw(name string) (law, bool) {
+	key := lawKey(name)
+	l := law{name: name}
+	for _, rule := range e.simplificationRules {
+		for _, candidate := range append([]string{rule.Name}, rule.Aliases...) {
+			if candidate := lawKey(candidate); candidate == key || candidate == strings.TrimSuffix(key, "s") {
+				l.rules = append(l.rules, rule.Apply)
+				break
+			}
+		}
+	}
+	return l, len(l.rules) > 0
+}
+
+// cleanUpRules fold the constants a law leaves behind, so that a step may
+// name the law doing the work and skip a + 1 = 1 or !0 = 1 afterwards.
+var cleanUpRules = []func(ast.Expression) (bool, ast.Expression){IdentityRule, NegatedConstantRule}
+
+// cleanUp applies the clean-up rules until none matches and returns the
+// flattened result.
+func cleanUp(expression ast.Expression) string {
+	expression = ast.Flatten(expression)
+	for changed := true; changed; {
+		changed = false
+		for _, rule := range cleanUpRules {
+			if matched, result := rewriteAll(expression, rule); matched {
+				expression, changed = ast.Flatten(result), true
+			}
+		}
+	}
+	return expression.Literal()
+}
+
+// rewrites returns every expression obtained by applying the rule once, at
+// a single subterm of the expression.
+func rewrites(expression ast.Expression, rule func(ast.Expression) (bool, ast.Expression)) []ast.Expression {
+	results := make([]ast.Expression, 0)
+	if matched, replacement := rule(ast.Clone(expression)); matched {
+		results = append(results, replacement)
+	}
+
+	switch expr := expression.(type) {
+	case *ast.PrefixExpression:
+		for _, right := range rewrites(expr.Right, rule) {
+			results = append(results, &ast.PrefixExpression{Token: expr.Token, Op: expr.Op, Right: right})
+		}
+	case *ast.InfixExpression:
+		for _, left := range rewrites(expr.Left, rule) {
+			results = append(results, &ast.InfixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Left: left, Right: ast.Clone(expr.Right)})
+		}
+		for _, right := range rewrites(expr.Right, rule) {
+			results = append(results, &ast.InfixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Left: ast.Clone(expr.Left), Right: right})
+		}
+	case *ast.NaryExpression:
+		for i, operand := range expr.Operands {
+			for _, rewritten := range rewrites(operand, rule) {
+				operands := make([]ast.Expression, 0, len(expr.Operands))
+				for j, other := range expr.Operands {
+					if j == i {
+						operands = append(operands, rewritten)
+					} else {
+						operands = append(operands, ast.Clone(other))
+					}
+				}
+				results = append(results, ast.NewNary(expr.Action, operands))
+			}
+		}
+	}
+	return results
+}
+
+// rewriteAll applies the rule at every outermost subterm it matches, the
+// first result is false when it matches nowhere.
+func rewriteAll(expression ast.Expression, rule func(ast.Expression) (bool, ast.Expression)) (bool, ast.Expression) {
+	if matched, replacement := rule(ast.Clone(expression)); matched {
+		return true, replacement
+	}
+
+	switch expr := expression.(type) {
+	case *ast.PrefixExpression:
+		matched, right := rewriteAll(expr.Right, rule)
+		return matched, &ast.PrefixExpression{Token: expr.Token, Op: expr.Op, Right: right}
+	case *ast.InfixExpression:
+		leftMatched, left := rewriteAll(expr.Left, rule)
+		rightMatched, right := rewriteAll(expr.Right, rule)
+		return leftMatched || rightMatched, &ast.InfixExpression{Token: expr.Token, Op: expr.Op, Action: expr.Action, Left: left, Right: right}
+	case *ast.NaryExpression:
+		matched := false
+		operands := make([]ast.Expression, 0, len(expr.Operands))
+		for _, operand := range expr.Operands {
+			operandMatched, rewritten := rewriteAll(operand, rule)
+			matched = matched || operandMatched
+			operands = append(operands, rewritten)
+		}
+		return matched, ast.NewNary(expr.Action, operands)
+	}
+	return false, expression
+}
+
+// justifies reports whether the law turns from into to, applied once or at
+// all matching subterms and followed by the clean-up rules. Laws are
+// equations, so they may be used in either direction. Both expressions are
+// flattened like for Simplify, so the order and grouping of conjunctions and
+// disjunctions do not matter.
+func (l law) justifies(from ast.Expression, to ast.Expression) bool {
+	from, to = ast.Flatten(from), ast.Flatten(to)
+	for _, rule := range l.rules {
+		for _, direction := range [][2]ast.Expression{{from, to}, {to, from}} {
+			target := cleanUp(direction[1])
+			if matched, result := rewriteAll(direction[0], rule); matched && cleanUp(result) == target {
+				return true
+			}
+			if slices.ContainsFunc(rewrites(direction[0], rule), func(candidate ast.Expression) bool {
+				return cleanUp(candidate) == target
+			}) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkDerivationStep validates the step from one expression to the next,
+// it returns an empty string when the step is sound and justified by the
+// named law.
+func (e *Evaluator) checkDerivationStep(from ast.Expression, to ast.Expression, name string) string {
+	if equivalent, difference := Equivalent(from, to); !equivalent {
+		return fmt.Sprintf("not equivalent, they differ at %s", formatAssignment(getIdentifiersOfAll([]ast.Expression{from, to}), difference))
+	}
+	if name == "" {
+		return ""
+	}
+
+	l, ok := e.lookupLaw(name)
+	if !ok {
+		known := make([]string, 0, len(e.simplificationRules))
+		for _, rule := range e.simplificationRules {
+			known = merge(known, []string{rule.Name})
+		}
+		slices.Sort(known)
+		return fmt.Sprintf("unknown law %s, the known laws are %s", name, strings.Join(known, ", "))
+	}
+	if !l.justifies(from, to) {
+		return fmt.Sprintf("equivalent, but %s does not turn the previous expression into this one", l.name)
+	}
+	return ""
+}
+
+func (e *Evaluator) formatDerivation(steps []ast.Expression, names []string) string {
+	width := 0
+	for _, step := range steps {
+		width = max(width, len(step.Literal()))
+	}
+
+	faulty := 0
+	lines := []string{fmt.Sprintf("   %s", steps[0].Literal())}
+	for i := 1; i < len(steps); i++ {
+		line := fmt.Sprintf(" = %-*s", width, steps[i].Literal())
+		if names[i] != "" {
+			line += "  " + names[i]
+		}
+
+		if problem := e.checkDerivationStep(steps[i-1], steps[i], names[i]); problem != "" {
+			faulty++
+			line += fmt.Sprintf("  \033[31m✗ %s\033[0m", problem)
+		} else {
+			line += "  \033[32m✓\033[0m"
+		}
+		lines = append(lines, line)
+	}
+
+	if faulty > 0 {
+		lines = append(lines, fmt.Sprintf("%s %d faulty step(s)", bold("invalid derivation,"), faulty))
+	} else {
+		lines = append(lines, fmt.Sprintf("%s %s = %s", bold("valid derivation of"), steps[0].Literal(), steps[len(steps)-1].Literal()))
+	}
+	return strings.Join(lines, "\n")
+}
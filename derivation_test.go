@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/parser"
+	"github.com/terawatthour/logix/tokenizer"
+	"strings"
+	"testing"
+)
+
+func derive(t *testing.T, source string) string {
+	t.Helper()
+	tok := tokenizer.NewTokenizer(source)
+	if err := tok.Tokenize(); err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	stmt, err := parser.NewParser(tok).Parse()
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	return NewEvaluator().evaluate(stmt)
+}
+
+func TestDerivationLaws(t *testing.T) {
+	valid := []string{
+		"derive !(a * b) + a = (!a + !b) + a by De Morgan = 1 by complement",
+		"derive a * (a + b) = a by absorption",
+		"derive a + (!a * b) = a + b by complement absorption",
+	}
+	for _, source := range valid {
+		if output := derive(t, source); strings.Contains(output, "✗") {
+			t.Errorf("%s was rejected:\n%s", source, output)
+		}
+	}
+
+	if output := derive(t, "derive a * (a + b) = a by De Morgan"); !strings.Contains(output, "does not turn") {
+		t.Errorf("a step not made by the named law was accepted:\n%s", output)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/bdd"
+	"strings"
+)
+
+// newDiagramManager prepares a manager for the expression with the variables
+// in interleaved order and sifting enabled, extra identifiers are placed
+// after those of the expression.
+func newDiagramManager(expression ast.Expression, extra []string) *bdd.Manager {
+	order := merge(bdd.InterleavedOrder(expression), extra)
+	manager := bdd.NewManager(order...)
+	manager.MaxNodes = bddNodeLimit
+	manager.AutoReorder = true
+	return manager
+}
+
+type diagramStats struct {
+	name      string
+	nodes     int
+	variables []string
+}
+
+func formatDiagramStats(expression ast.Expression) string {
+	stats := make([]diagramStats, 0)
+	var before *bdd.Manager
+	var root bdd.Node
+
+	orders := []struct {
+		name  string
+		order []string
+	}{
+		{"first occurrence", getAllIdentifiers(expression, nil)},
+		{"dfs fan-in", bdd.DFSOrder(expression)},
+		{"interleaved", bdd.InterleavedOrder(expression)},
+	}
+	for _, order := range orders {
+		manager := bdd.NewManager(order.order...)
+		manager.MaxNodes = bddNodeLimit
+		f, err := manager.FromExpression(expression)
+		if err != nil {
+			return fmt.Sprintf("the diagram has more than %d nodes in %s order", bddNodeLimit, order.name)
+		}
+		if before == nil {
+			before, root = manager, f
+		}
+		stats = append(stats, diagramStats{order.name, manager.Size(f), manager.Variables()})
+	}
+
+	before.Sift(root)
+	stats = append(stats, diagramStats{"sifted", before.Size(root), before.Variables()})
+
+	width := 0
+	for _, s := range stats {
+		width = max(width, len(s.name))
+	}
+	lines := make([]string, 0, len(stats)+1)
+	lines = append(lines, bold(fmt.Sprintf("%-*s  %7s  %s", width, "order", "nodes", "variables")))
+	for _, s := range stats {
+		lines = append(lines, fmt.Sprintf("%-*s  %7d  %s", width, s.name, s.nodes, strings.Join(s.variables, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/sat"
+	"github.com/terawatthour/logix/tokenizer"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDIMACS writes the expression as a DIMACS CNF problem. Expressions
+// already in conjunctive normal form are written clause by clause, others
+// are Tseitin encoded with auxiliary variables numbered after the
+// identifiers. Comment lines map variable numbers back to identifiers.
+func WriteDIMACS(w io.Writer, expression ast.Expression) error {
+	idents := getAllIdentifiers(expression, nil)
+	variables := make(map[string]int)
+	for i, ident := range idents {
+		variables[ident] = i + 1
+	}
+
+	numVars := len(idents)
+	clauses, ok := cnfClauses(expression, variables)
+	if !ok {
+		encoding := newCNFEncoding(sat.NewSolver(), idents)
+		encoding.assert(expression)
+		clauses = encoding.clauses
+		numVars = encoding.solver.NumVars()
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "c logix %s\n", expression.Literal())
+	for i, ident := range idents {
+		fmt.Fprintf(out, "c var %d %s\n", i+1, ident)
+	}
+	if numVars > len(idents) {
+		fmt.Fprintf(out, "c auxiliary variables %d-%d\n", len(idents)+1, numVars)
+	}
+	fmt.Fprintf(out, "p cnf %d %d\n", numVars, len(clauses))
+	for _, clause := range clauses {
+		for _, lit := range clause {
+			fmt.Fprintf(out, "%d ", lit)
+		}
+		fmt.Fprintln(out, "0")
+	}
+	return out.Flush()
+}
+
+// cnfClauses reads the clauses of an expression that is a conjunction of
+// disjunctions of literals, the second result is false for any other shape.
+func cnfClauses(expression ast.Expression, variables map[string]int) ([][]int, bool) {
+	switch expr := expression.(type) {
+	case *ast.Boolean:
+		if expr.Value {
+			return [][]int{}, true
+		}
+		return [][]int{{}}, true
+	case *ast.InfixExpression:
+		if expr.Op == "*" || expr.Op == "&" {
+			left, ok := cnfClauses(expr.Left, variables)
+			if !ok {
+				return nil, false
+			}
+			right, ok := cnfClauses(expr.Right, variables)
+			if !ok {
+				return nil, false
+			}
+			return append(left, right...), true
+		}
+	}
+
+	clause, ok := cnfClause(expression, variables)
+	if !ok {
+		return nil, false
+	}
+	return [][]int{clause}, true
+}
+
+func cnfClause(expression ast.Expression, variables map[string]int) ([]int, bool) {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return []int{variables[expr.Value]}, true
+	case *ast.PrefixExpression:
+		if ident, ok := expr.Right.(*ast.Identifier); ok && expr.Op == "!" {
+			return []int{-variables[ident.Value]}, true
+		}
+	case *ast.InfixExpression:
+		if expr.Op == "+" || expr.Op == "|" {
+			left, ok := cnfClause(expr.Left, variables)
+			if !ok {
+				return nil, false
+			}
+			right, ok := cnfClause(expr.Right, variables)
+			if !ok {
+				return nil, false
+			}
+			return append(left, right...), true
+		}
+	}
+	return nil, false
+}
+
+// ReadDIMACS loads a DIMACS CNF problem as a conjunction of clauses. Variable
+// names are taken from "c var <n> <name>" comments when present, other
+// variables are called x<n>, with underscores appended while that name is
+// taken. A name must be an identifier and may be given to one variable only,
+// so the formula parses back.
+func ReadDIMACS(r io.Reader) (ast.Expression, error) {
+	names := make(map[int]string)
+	used := make(map[string]bool)
+	clauses := make([][]int, 0)
+	current := make([]int, 0)
+	declaredVars, declaredClauses := -1, -1
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if fields[0] == "%" {
+			break
+		}
+
+		switch fields[0] {
+		case "c":
+			if len(fields) == 4 && fields[1] == "var" {
+				if v, err := strconv.Atoi(fields[2]); err == nil && v > 0 {
+					switch {
+					case !isIdentifier(fields[3]):
+						return nil, fmt.Errorf("line %d: %s is not an identifier", line, fields[3])
+					case names[v] != "":
+						return nil, fmt.Errorf("line %d: variable %d is already named %s", line, v, names[v])
+					case used[fields[3]]:
+						return nil, fmt.Errorf("line %d: %s names another variable", line, fields[3])
+					}
+					names[v] = fields[3]
+					used[fields[3]] = true
+				}
+			}
+			continue
+		case "p":
+			if len(fields) != 4 || fields[1] != "cnf" {
+				return nil, fmt.Errorf("line %d: expected \"p cnf <variables> <clauses>\"", line)
+			}
+			var err error
+			if declaredVars, err = strconv.Atoi(fields[2]); err != nil {
+				return nil, fmt.Errorf("line %d: invalid variable count %s", line, fields[2])
+			}
+			if declaredClauses, err = strconv.Atoi(fields[3]); err != nil {
+				return nil, fmt.Errorf("line %d: invalid clause count %s", line, fields[3])
+			}
+			continue
+		}
+
+		if declaredVars < 0 {
+			return nil, fmt.Errorf("line %d: clause before the problem line", line)
+		}
+		for _, field := range fields {
+			lit, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid literal %s", line, field)
+			}
+			if lit == 0 {
+				clauses = append(clauses, current)
+				current = make([]int, 0)
+				continue
+			}
+			if abs(lit) > declaredVars {
+				return nil, fmt.Errorf("line %d: variable %d exceeds the declared %d", line, abs(lit), declaredVars)
+			}
+			current = append(current, lit)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if declaredVars < 0 {
+		return nil, fmt.Errorf("missing problem line")
+	}
+	if len(current) > 0 {
+		clauses = append(clauses, current)
+	}
+	if declaredClauses >= 0 && len(clauses) != declaredClauses {
+		return nil, fmt.Errorf("expected %d clauses, found %d", declaredClauses, len(clauses))
+	}
+
+	name := func(v int) string {
+		if n, ok := names[v]; ok {
+			return n
+		}
+		n := fmt.Sprintf("x%d", v)
+		for used[n] {
+			n += "_"
+		}
+		names[v] = n
+		used[n] = true
+		return n
+	}
+
+	var result ast.Expression
+	for _, clause := range clauses {
+		var disjunction ast.Expression = &ast.Boolean{Value: false}
+		for i, lit := range clause {
+			var literal ast.Expression = &ast.Identifier{Value: name(abs(lit))}
+			if lit < 0 {
+				literal = &ast.PrefixExpression{Op: "!", Right: literal}
+			}
+			if i == 0 {
+				disjunction = literal
+			} else {
+				disjunction = &ast.InfixExpression{Op: "+", Action: "or", Left: disjunction, Right: literal}
+			}
+		}
+		if result == nil {
+			result = disjunction
+		} else {
+			result = &ast.InfixExpression{Op: "*", Action: "and", Left: result, Right: disjunction}
+		}
+	}
+	if result == nil {
+		return &ast.Boolean{Value: true}, nil
+	}
+	return result, nil
+}
+
+// isIdentifier reports whether the name reads back as a single identifier.
+func isIdentifier(name string) bool {
+	tok := tokenizer.NewTokenizer(name)
+	if err := tok.Tokenize(); err != nil || len(tok.Tokens) != 1 {
+		return false
+	}
+	return tok.Tokens[0].Kind == tokenizer.TOK_IDENT && tok.Tokens[0].Literal == name
+}
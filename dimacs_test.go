@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/parser"
+	"github.com/terawatthour/logix/tokenizer"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// readBack parses the text the import statement prints.
+func readBack(t *testing.T, source string) ast.Expression {
+	t.Helper()
+	tok := tokenizer.NewTokenizer(source)
+	if err := tok.Tokenize(); err != nil {
+		t.Fatal(err)
+	}
+	expression, err := parser.NewParser(tok).ParseExpression()
+	if err != nil {
+		t.Fatalf("%s does not parse back: %v", source, err)
+	}
+	return expression
+}
+
+func TestDIMACSRoundTrip(t *testing.T) {
+	expressions := []ast.Expression{
+		parseRuleSide("(a + !b) * (b + c) * !a"),
+		parseRuleSide("(x2 + x3) * !x1"),
+		parseRuleSide("x4 <-> (a ^ b)"),
+		parseRuleSide("1"),
+		parseRuleSide("0"),
+	}
+	r := rand.New(rand.NewSource(1))
+	for len(expressions) < 200 {
+		data := make([]byte, r.Intn(20))
+		r.Read(data)
+		expressions = append(expressions, fuzzExpression(data))
+	}
+
+	for _, expression := range expressions {
+		var builder strings.Builder
+		if err := WriteDIMACS(&builder, expression); err != nil {
+			t.Fatal(err)
+		}
+		imported, err := ReadDIMACS(strings.NewReader(builder.String()))
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", expression.Literal(), err, builder.String())
+		}
+		imported = readBack(t, imported.Literal())
+
+		// auxiliary variables of a Tseitin encoding are defined by the
+		// identifiers, so the import implies the input and has as many
+		// models over its identifiers
+		idents := getAllIdentifiers(expression, nil)
+		implication := &ast.InfixExpression{Op: "->", Action: "->", Left: imported, Right: expression}
+		if !IsValid(implication) {
+			t.Errorf("%s imported as %s, which does not imply it", expression.Literal(), imported.Literal())
+		}
+		if got, want := CountProjectedModels(imported, idents), CountModels(expression); got.Cmp(want) != 0 {
+			t.Errorf("%s imported as %s, %s models over %v instead of %s", expression.Literal(), imported.Literal(), got, idents, want)
+		}
+		if _, ok := cnfClauses(expression, map[string]int{}); ok {
+			if equivalent, _ := Equivalent(expression, imported); !equivalent {
+				t.Errorf("the clauses %s imported as the inequivalent %s", expression.Literal(), imported.Literal())
+			}
+		}
+	}
+}
+
+func TestReadDIMACSNames(t *testing.T) {
+	imported, err := ReadDIMACS(strings.NewReader("c var 1 x2\np cnf 2 1\n1 -2 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := imported.Literal(); got != "(x2 + !x2_)" {
+		t.Errorf("imported %s, want (x2 + !x2_)", got)
+	}
+
+	for _, source := range []string{
+		"c var 1 a-b\np cnf 1 1\n1 0\n",
+		"c var 1 true\np cnf 1 1\n1 0\n",
+		"c var 1 a\nc var 2 a\np cnf 2 1\n1 2 0\n",
+		"c var 1 a\nc var 1 b\np cnf 1 1\n1 0\n",
+	} {
+		if _, err := ReadDIMACS(strings.NewReader(source)); err == nil {
+			t.Errorf("%q was accepted", source)
+		}
+	}
+}
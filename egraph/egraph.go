@@ -0,0 +1,198 @@
+// Package egraph implements equality saturation over propositional
+// formulas. An e-graph stores many equivalent formulas at once: rules add
+// the formulas they rewrite to next to the ones they matched, so no rewrite
+// ever loses a form, and the cheapest formula is extracted at the end.
+package egraph
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"slices"
+	"strconv"
+)
+
+// ClassID identifies an equivalence class of the graph.
+type ClassID int
+
+// Node is a connective applied to equivalence classes. Op is the action of
+// the connective, "!" for negation, or "identifier" and "boolean" for leaves
+// which keep their name or literal in Value.
+type Node struct {
+	Op       string
+	Value    string
+	Children []ClassID
+}
+
+func (n Node) key() string {
+	key := make([]byte, 0, len(n.Op)+len(n.Value)+8*len(n.Children))
+	key = append(key, n.Op...)
+	key = append(key, ' ')
+	key = append(key, n.Value...)
+	for _, child := range n.Children {
+		key = append(key, ' ')
+		key = strconv.AppendInt(key, int64(child), 10)
+	}
+	return string(key)
+}
+
+// Graph is an e-graph, a set of nodes partitioned into classes of
+// equivalent nodes. Nodes are hash-consed, a node with the same operator and
+// the same child classes is stored once.
+type Graph struct {
+	parents []ClassID
+	memo    map[string]entry
+	classes map[ClassID][]Node
+	// uses holds the keys of the nodes with the class as a child, they are
+	// hashed again when the class is merged into another.
+	uses map[ClassID][]string
+	// pending holds classes found equal while hashing nodes again, they are
+	// merged by Rebuild.
+	pending [][2]ClassID
+}
+
+type entry struct {
+	node  Node
+	class ClassID
+}
+
+func New() *Graph {
+	return &Graph{
+		parents: make([]ClassID, 0),
+		memo:    make(map[string]entry),
+		classes: make(map[ClassID][]Node),
+		uses:    make(map[ClassID][]string),
+	}
+}
+
+// Find returns the canonical identifier of the class.
+func (g *Graph) Find(id ClassID) ClassID {
+	for g.parents[id] != id {
+		g.parents[id] = g.parents[g.parents[id]]
+		id = g.parents[id]
+	}
+	return id
+}
+
+// Size returns the number of nodes in the graph.
+func (g *Graph) Size() int {
+	return len(g.memo)
+}
+
+// Classes returns the number of classes in the graph.
+func (g *Graph) Classes() int {
+	return len(g.classes)
+}
+
+func (g *Graph) canonical(node Node) Node {
+	children := make([]ClassID, len(node.Children))
+	for i, child := range node.Children {
+		children[i] = g.Find(child)
+	}
+	return Node{Op: node.Op, Value: node.Value, Children: children}
+}
+
+// insert stores a canonical node under its key.
+func (g *Graph) insert(node Node, class ClassID) {
+	key := node.key()
+	g.memo[key] = entry{node: node, class: class}
+	for _, child := range node.Children {
+		g.uses[child] = append(g.uses[child], key)
+	}
+}
+
+func (g *Graph) addNode(node Node) ClassID {
+	node = g.canonical(node)
+	if e, ok := g.memo[node.key()]; ok {
+		return g.Find(e.class)
+	}
+	id := ClassID(len(g.parents))
+	g.parents = append(g.parents, id)
+	g.insert(node, id)
+	g.classes[id] = []Node{node}
+	return id
+}
+
+// Add inserts the formula and returns its class.
+func (g *Graph) Add(expression ast.Expression) ClassID {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return g.addNode(Node{Op: "identifier", Value: expr.Value})
+	case *ast.Boolean:
+		return g.addNode(Node{Op: "boolean", Value: expr.Literal()})
+	case *ast.PrefixExpression:
+		return g.addNode(Node{Op: expr.Op, Children: []ClassID{g.Add(expr.Right)}})
+	case *ast.InfixExpression:
+		return g.addNode(Node{Op: expr.Action, Children: []ClassID{g.Add(expr.Left), g.Add(expr.Right)}})
+	case *ast.NaryExpression:
+		return g.Add(ast.Binary(expr))
+	}
+	panic("unreachable")
+}
+
+// Union merges two classes and reports whether they were distinct. The
+// older class stays canonical, so the result of a run does not depend on
+// the order of the unions. The nodes using the merged class are hashed again
+// at once, the graph must be rebuilt before it is searched again.
+func (g *Graph) Union(a ClassID, b ClassID) bool {
+	a, b = g.Find(a), g.Find(b)
+	if a == b {
+		return false
+	}
+	if b < a {
+		a, b = b, a
+	}
+	g.parents[b] = a
+	g.classes[a] = append(g.classes[a], g.classes[b]...)
+	delete(g.classes, b)
+
+	uses := g.uses[b]
+	delete(g.uses, b)
+	for _, key := range uses {
+		e, ok := g.memo[key]
+		if !ok {
+			continue
+		}
+		delete(g.memo, key)
+		node := g.canonical(e.node)
+		if existing, ok := g.memo[node.key()]; ok {
+			g.pending = append(g.pending, [2]ClassID{existing.class, e.class})
+			continue
+		}
+		g.insert(node, e.class)
+	}
+	return true
+}
+
+// Rebuild restores the invariants after unions: classes whose nodes became
+// equal are merged, and the nodes of every class are listed again in the
+// order of their keys, so that searches do not depend on the order of the
+// map.
+func (g *Graph) Rebuild() {
+	for len(g.pending) > 0 {
+		pair := g.pending[len(g.pending)-1]
+		g.pending = g.pending[:len(g.pending)-1]
+		g.Union(pair[0], pair[1])
+	}
+
+	g.classes = make(map[ClassID][]Node)
+	keys := make([]string, 0, len(g.memo))
+	for key := range g.memo {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		e := g.memo[key]
+		e.class = g.Find(e.class)
+		g.memo[key] = e
+		g.classes[e.class] = append(g.classes[e.class], e.node)
+	}
+}
+
+// ids returns the canonical classes in increasing order.
+func (g *Graph) ids() []ClassID {
+	ids := make([]ClassID, 0, len(g.classes))
+	for id := range g.classes {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
@@ -0,0 +1,132 @@
+package egraph
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/parser"
+	"github.com/terawatthour/logix/tokenizer"
+	"testing"
+)
+
+func parse(t *testing.T, source string) ast.Expression {
+	t.Helper()
+	tok := tokenizer.NewTokenizer(source)
+	if err := tok.Tokenize(); err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	expression, err := parser.NewParser(tok).ParseExpression()
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	return expression
+}
+
+// checkCanonical fails when a node of the memo has a child that is not
+// canonical, belongs to a class that is not, or is missing from its class.
+func checkCanonical(t *testing.T, g *Graph) {
+	t.Helper()
+	for key, e := range g.memo {
+		if node := g.canonical(e.node); node.key() != key {
+			t.Fatalf("the node %s is stored under %s", node.key(), key)
+		}
+		if g.Find(e.class) != e.class {
+			t.Fatalf("the node %s belongs to the merged class %d", key, e.class)
+		}
+		found := false
+		for _, node := range g.classes[e.class] {
+			found = found || node.key() == key
+		}
+		if !found {
+			t.Fatalf("the node %s is missing from its class %d", key, e.class)
+		}
+	}
+}
+
+func TestUnionKeepsMemoCanonical(t *testing.T) {
+	g := New()
+	left := g.Add(parse(t, "!(a * b) + (a * b)"))
+	right := g.Add(parse(t, "!(a * c) + (a * c)"))
+	b, c := g.Add(parse(t, "b")), g.Add(parse(t, "c"))
+	size, classes := g.Size(), g.Classes()
+
+	if !g.Union(b, c) {
+		t.Fatal("the distinct classes of b and c were not merged")
+	}
+	if g.Union(c, b) {
+		t.Error("merging b and c again reported distinct classes")
+	}
+	g.Rebuild()
+	checkCanonical(t, g)
+
+	// a * b and a * c become one node, and so do the nodes above them
+	if g.Find(left) != g.Find(right) {
+		t.Error("the congruent classes of the roots were not merged")
+	}
+	if got, want := g.Size(), size-3; got != want {
+		t.Errorf("%d nodes after the union, want %d", got, want)
+	}
+	if got, want := g.Classes(), classes-4; got != want {
+		t.Errorf("%d classes after the union, want %d", got, want)
+	}
+	if g.Add(parse(t, "a * c")) != g.Add(parse(t, "a * b")) {
+		t.Error("adding a * c again made a new class")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	cases := []struct {
+		model    string
+		formulas []string
+		want     string
+		cost     int
+	}{
+		{"literals", []string{"a + (a * b)", "a * (a + b)", "a"}, "a", 1},
+		{"operators", []string{"!a * !b", "!(a + b)"}, "!(a + b)", 2},
+		{"depth", []string{"((a * b) * c) * d", "(a * b) * (c * d)"}, "((a * b) * (c * d))", 2},
+		{"nand", []string{"(a * !b) + (!a * b)", "(a + b) * !(a * b)", "a ^ b"}, "(a ^ b)", 4},
+		{"nand", []string{"!a + b", "a -> b"}, "(a -> b)", 2},
+	}
+	for _, c := range cases {
+		g := New()
+		root := g.Add(parse(t, c.formulas[0]))
+		for _, formula := range c.formulas[1:] {
+			g.Union(root, g.Add(parse(t, formula)))
+		}
+		g.Rebuild()
+
+		result, cost := g.Extract(root, CostModels[c.model])
+		if result.Literal() != c.want || cost != c.cost {
+			t.Errorf("%v by %s extracted %s of cost %d, want %s of cost %d", c.formulas, c.model, result.Literal(), cost, c.want, c.cost)
+		}
+	}
+}
+
+func TestSaturate(t *testing.T) {
+	rules := make([]*Rule, 0, 3)
+	for _, equation := range [][2]string{
+		{"!A * !B", "!(A + B)"},
+		{"A * B", "B * A"},
+		{"!!A", "A"},
+	} {
+		rule, err := NewRule(equation[0], parse(t, equation[0]), parse(t, equation[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, rule)
+	}
+
+	g := New()
+	root := g.Add(parse(t, "!!(!a * !b)"))
+	report := g.Saturate(rules, Limits{Nodes: 1000, Iterations: 10})
+	if report.Stop != Saturated {
+		t.Fatalf("stopped by %s", report.Stop)
+	}
+	checkCanonical(t, g)
+	if result, cost := g.Extract(root, OperatorCount); result.Literal() != "!(a + b)" || cost != 2 {
+		t.Errorf("extracted %s of cost %d, want !(a + b) of cost 2", result.Literal(), cost)
+	}
+
+	report = New().Saturate(rules, Limits{Nodes: 1000, Iterations: 10})
+	if report.Stop != Saturated || report.Nodes != 0 {
+		t.Errorf("the empty graph ended with %d nodes, %s", report.Nodes, report.Stop)
+	}
+}
@@ -0,0 +1,138 @@
+package egraph
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+)
+
+// CostFunction gives the cost of a node from the costs of its children. Costs
+// must not decrease when a child gets more expensive.
+type CostFunction func(node Node, children []int) int
+
+// LiteralCount counts the occurrences of variables.
+func LiteralCount(node Node, children []int) int {
+	cost := sum(children)
+	if node.Op == "identifier" {
+		cost++
+	}
+	return cost
+}
+
+// OperatorCount counts the connectives, negations included.
+func OperatorCount(node Node, children []int) int {
+	cost := sum(children)
+	if node.Op != "identifier" && node.Op != "boolean" {
+		cost++
+	}
+	return cost
+}
+
+// Depth measures the longest path from the root to a leaf.
+func Depth(node Node, children []int) int {
+	if len(children) == 0 {
+		return 0
+	}
+	return 1 + max(children[0], children[len(children)-1])
+}
+
+// nandGates is the number of two-input NAND gates building each connective
+// from its inputs.
+var nandGates = map[string]int{
+	"!":   1,
+	"and": 2,
+	"or":  3,
+	"->":  2,
+	"xor": 4,
+	"<->": 5,
+}
+
+// NANDCount counts the two-input NAND gates of a circuit built from the
+// formula, without sharing equal subformulas.
+func NANDCount(node Node, children []int) int {
+	return nandGates[node.Op] + sum(children)
+}
+
+// CostModels names the cost functions, for selection by the user.
+var CostModels = map[string]CostFunction{
+	"literals":  LiteralCount,
+	"operators": OperatorCount,
+	"depth":     Depth,
+	"nand":      NANDCount,
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+type choice struct {
+	node Node
+	cost int
+	// size breaks ties between nodes of equal cost, the smaller formula is
+	// preferred.
+	size int
+}
+
+// Extract returns the cheapest formula of the class under the cost function
+// and its cost.
+func (g *Graph) Extract(id ClassID, cost CostFunction) (ast.Expression, int) {
+	best := make(map[ClassID]choice)
+	for changed := true; changed; {
+		changed = false
+		for _, class := range g.ids() {
+			for _, node := range g.classes[class] {
+				costs := make([]int, 0, len(node.Children))
+				size := 1
+				known := true
+				for _, child := range node.Children {
+					c, ok := best[g.Find(child)]
+					if !ok {
+						known = false
+						break
+					}
+					costs = append(costs, c.cost)
+					size += c.size
+				}
+				if !known {
+					continue
+				}
+				candidate := choice{node: node, cost: cost(node, costs), size: size}
+				current, ok := best[class]
+				if !ok || candidate.cost < current.cost || (candidate.cost == current.cost && candidate.size < current.size) {
+					best[class] = candidate
+					changed = true
+				}
+			}
+		}
+	}
+
+	root := g.Find(id)
+	return g.build(root, best), best[root].cost
+}
+
+var infixOperators = map[string]string{
+	"and": "*",
+	"or":  "+",
+	"xor": "^",
+	"->":  "->",
+	"<->": "<->",
+}
+
+func (g *Graph) build(id ClassID, best map[ClassID]choice) ast.Expression {
+	node := best[g.Find(id)].node
+	switch node.Op {
+	case "identifier":
+		return &ast.Identifier{Value: node.Value}
+	case "boolean":
+		return &ast.Boolean{Value: node.Value == "1"}
+	case "!":
+		return &ast.PrefixExpression{Op: "!", Right: g.build(node.Children[0], best)}
+	}
+	if op, ok := infixOperators[node.Op]; ok {
+		return &ast.InfixExpression{Op: op, Action: node.Op, Left: g.build(node.Children[0], best), Right: g.build(node.Children[1], best)}
+	}
+	panic(fmt.Sprintf("egraph: unknown operator %s", node.Op))
+}
@@ -0,0 +1,295 @@
+package egraph
+
+import (
+	"errors"
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/rewrite"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Rule states that formulas matching Pattern equal Replacement, with
+// metavariables written like for rewrite.Rule. Saturation never removes the
+// matched formula, so a rule and its reverse may both be used.
+type Rule struct {
+	Name        string
+	Pattern     ast.Expression
+	Replacement ast.Expression
+}
+
+// NewRule checks that the pattern is not a bare metavariable and that the
+// replacement only uses metavariables bound by the pattern.
+func NewRule(name string, pattern ast.Expression, replacement ast.Expression) (*Rule, error) {
+	if ident, ok := pattern.(*ast.Identifier); ok && rewrite.IsMetavariable(ident.Value) {
+		return nil, errors.New("egraph: the pattern must not be a bare metavariable")
+	}
+	bound := rewrite.Metavariables(pattern)
+	for _, name := range rewrite.Metavariables(replacement) {
+		found := false
+		for _, b := range bound {
+			found = found || b == name
+		}
+		if !found {
+			return nil, fmt.Errorf("egraph: metavariable %s of the replacement does not occur in the pattern", name)
+		}
+	}
+	return &Rule{Name: name, Pattern: ast.Binary(pattern), Replacement: ast.Binary(replacement)}, nil
+}
+
+// Limits bound a saturation run, a zero field means no bound.
+type Limits struct {
+	Nodes      int
+	Time       time.Duration
+	Iterations int
+}
+
+// StopReason tells why a saturation run ended.
+type StopReason string
+
+const (
+	Saturated      StopReason = "saturated"
+	NodeLimit      StopReason = "node limit"
+	TimeLimit      StopReason = "time limit"
+	IterationLimit StopReason = "iteration limit"
+)
+
+// Report describes a saturation run.
+type Report struct {
+	Iterations int
+	Nodes      int
+	Classes    int
+	Stop       StopReason
+}
+
+type bindings map[string]ClassID
+
+type match struct {
+	rule     *Rule
+	class    ClassID
+	bindings bindings
+}
+
+// Saturate applies the rules to every class until none of them adds a new
+// equality, or until one of the limits is hit. Every iteration first
+// searches all matches and then applies them, so the order of the rules does
+// not matter.
+func (g *Graph) Saturate(rules []*Rule, limits Limits) *Report {
+	start := time.Now()
+	report := &Report{}
+	defer func() {
+		g.Rebuild()
+		report.Nodes, report.Classes = g.Size(), g.Classes()
+	}()
+
+	exceeded := func() StopReason {
+		if limits.Nodes > 0 && g.Size() >= limits.Nodes {
+			return NodeLimit
+		}
+		if limits.Time > 0 && time.Since(start) >= limits.Time {
+			return TimeLimit
+		}
+		return ""
+	}
+
+	g.Rebuild()
+	for {
+		if limits.Iterations > 0 && report.Iterations >= limits.Iterations {
+			report.Stop = IterationLimit
+			return report
+		}
+		report.Iterations++
+
+		matches := make([]match, 0)
+		matcher := g.newMatcher()
+		for _, id := range g.ids() {
+			for _, rule := range rules {
+				for _, b := range matcher.search(rule.Pattern, id) {
+					matches = append(matches, match{rule: rule, class: id, bindings: b})
+				}
+			}
+			if stop := exceeded(); stop != "" {
+				report.Stop = stop
+				return report
+			}
+		}
+
+		changed := false
+		for _, m := range matches {
+			id := g.instantiate(m.rule.Replacement, m.bindings)
+			changed = g.Union(m.class, id) || changed
+			if stop := exceeded(); stop != "" {
+				report.Stop = stop
+				return report
+			}
+		}
+		g.Rebuild()
+
+		if !changed {
+			report.Stop = Saturated
+			return report
+		}
+	}
+}
+
+// matcher searches the patterns of one iteration. The same subpattern is
+// searched in the same class from many nodes above it, so the results are
+// kept per subpattern and class, and the results for the two sides of a
+// connective are joined on the metavariables they share.
+type matcher struct {
+	g    *Graph
+	memo map[matchKey][]bindings
+}
+
+type matchKey struct {
+	pattern ast.Expression
+	class   ClassID
+}
+
+func (g *Graph) newMatcher() *matcher {
+	return &matcher{g: g, memo: make(map[matchKey][]bindings)}
+}
+
+// search returns every binding of the metavariables of the pattern under
+// which it matches a node of the class.
+func (m *matcher) search(pattern ast.Expression, id ClassID) []bindings {
+	id = m.g.Find(id)
+	key := matchKey{pattern: pattern, class: id}
+	if results, ok := m.memo[key]; ok {
+		return results
+	}
+
+	results := make([]bindings, 0)
+	switch p := pattern.(type) {
+	case *ast.Identifier:
+		if rewrite.IsMetavariable(p.Value) {
+			results = append(results, bindings{p.Value: id})
+			break
+		}
+		for _, node := range m.g.classes[id] {
+			if node.Op == "identifier" && node.Value == p.Value {
+				results = append(results, bindings{})
+				break
+			}
+		}
+	case *ast.Boolean:
+		for _, node := range m.g.classes[id] {
+			if node.Op == "boolean" && node.Value == p.Literal() {
+				results = append(results, bindings{})
+				break
+			}
+		}
+	case *ast.PrefixExpression:
+		for _, node := range m.g.classes[id] {
+			if node.Op == p.Op {
+				results = append(results, m.search(p.Right, node.Children[0])...)
+			}
+		}
+	case *ast.InfixExpression:
+		for _, node := range m.g.classes[id] {
+			if node.Op != p.Action {
+				continue
+			}
+			left := m.search(p.Left, node.Children[0])
+			if len(left) == 0 {
+				continue
+			}
+			results = append(results, join(left, m.search(p.Right, node.Children[1]))...)
+		}
+	}
+	results = unique(results)
+	m.memo[key] = results
+	return results
+}
+
+// join combines every pair of bindings that agree on their common
+// metavariables. All bindings of a side bind the same metavariables, so the
+// right side is indexed by the values of the common ones.
+func join(left []bindings, right []bindings) []bindings {
+	results := make([]bindings, 0)
+	if len(right) == 0 {
+		return results
+	}
+	common := make([]string, 0)
+	for name := range right[0] {
+		if _, ok := left[0][name]; ok {
+			common = append(common, name)
+		}
+	}
+	index := make(map[string][]bindings)
+	for _, r := range right {
+		key := r.project(common)
+		index[key] = append(index[key], r)
+	}
+
+	for _, l := range left {
+		for _, r := range index[l.project(common)] {
+			merged := make(bindings, len(l)+len(r))
+			for name, id := range l {
+				merged[name] = id
+			}
+			for name, id := range r {
+				merged[name] = id
+			}
+			results = append(results, merged)
+		}
+	}
+	return results
+}
+
+// unique drops repeated bindings, different nodes of a class often bind the
+// metavariables to the same classes.
+func unique(results []bindings) []bindings {
+	if len(results) < 2 {
+		return results
+	}
+	seen := make(map[string]bool, len(results))
+	kept := results[:0]
+	for _, b := range results {
+		if key := b.key(); !seen[key] {
+			seen[key] = true
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
+func (b bindings) key() string {
+	names := make([]string, 0, len(b))
+	for name := range b {
+		names = append(names, name)
+	}
+	return b.project(names)
+}
+
+// project writes the classes bound to the metavariables as a key, in the
+// order of their sorted names.
+func (b bindings) project(names []string) string {
+	slices.Sort(names)
+	key := make([]byte, 0, 8*len(names))
+	for _, name := range names {
+		key = append(key, name...)
+		key = append(key, '=')
+		key = strconv.AppendInt(key, int64(b[name]), 10)
+		key = append(key, ' ')
+	}
+	return string(key)
+}
+
+// instantiate adds the replacement with its metavariables standing for the
+// bound classes.
+func (g *Graph) instantiate(replacement ast.Expression, b bindings) ClassID {
+	switch expr := replacement.(type) {
+	case *ast.Identifier:
+		if bound, ok := b[expr.Value]; ok {
+			return g.Find(bound)
+		}
+		return g.addNode(Node{Op: "identifier", Value: expr.Value})
+	case *ast.PrefixExpression:
+		return g.addNode(Node{Op: expr.Op, Children: []ClassID{g.instantiate(expr.Right, b)}})
+	case *ast.InfixExpression:
+		return g.addNode(Node{Op: expr.Action, Children: []ClassID{g.instantiate(expr.Left, b), g.instantiate(expr.Right, b)}})
+	}
+	return g.Add(replacement)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/sat"
+)
+
+// Consistent decides whether all formulas can be true at once, returning an
+// assignment that makes them true when they can.
+func Consistent(formulas []ast.Expression) (bool, map[string]bool) {
+	solver := sat.NewSolver()
+	encoding := newCNFEncoding(solver, getIdentifiersOfAll(formulas))
+	for _, formula := range formulas {
+		encoding.assert(formula)
+	}
+
+	if !solver.Solve() {
+		return false, nil
+	}
+	return true, encoding.assignment()
+}
+
+// Entails decides whether the conclusion is true under every assignment that
+// makes all premises true. When it is not, the returned countermodel makes
+// the premises true and the conclusion false.
+func Entails(premises []ast.Expression, conclusion ast.Expression) (bool, map[string]bool) {
+	formulas := append(append(make([]ast.Expression, 0, len(premises)+1), premises...), &ast.PrefixExpression{Op: "!", Right: conclusion})
+	consistent, countermodel := Consistent(formulas)
+	return !consistent, countermodel
+}
+
+func getIdentifiersOfAll(formulas []ast.Expression) []string {
+	idents := make([]string, 0)
+	for _, formula := range formulas {
+		idents = getAllIdentifiers(formula, idents)
+	}
+	return idents
+}
+
+func formatEntailment(premises []ast.Expression, conclusion ast.Expression) string {
+	entails, countermodel := Entails(premises, conclusion)
+	if entails {
+		return bold("entailed")
+	}
+	idents := getIdentifiersOfAll(append(append([]ast.Expression{}, premises...), conclusion))
+	return fmt.Sprintf("%s countermodel: %s", bold("not entailed,"), formatAssignment(idents, countermodel))
+}
+
+func formatConsistency(formulas []ast.Expression) string {
+	consistent, model := Consistent(formulas)
+	if !consistent {
+		return bold("inconsistent")
+	}
+	return fmt.Sprintf("%s model: %s", bold("consistent,"), formatAssignment(getIdentifiersOfAll(formulas), model))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"math/big"
+)
+
+// bddIdentifierLimit is the largest number of identifiers for which
+// equivalence checks and model counts are done with decision diagrams.
+const bddIdentifierLimit = 64
+
+// bddNodeLimit bounds the diagrams built for those checks, the SAT based
+// methods take over when it is exceeded.
+const bddNodeLimit = 1 << 20
+
+// Equivalent decides whether two expressions agree under every assignment,
+// returning an assignment on which they differ when they do not.
+func Equivalent(left ast.Expression, right ast.Expression) (bool, map[string]bool) {
+	idents := getIdentifiersOfAll([]ast.Expression{left, right})
+	if len(idents) <= bddIdentifierLimit {
+		manager := newDiagramManager(&ast.InfixExpression{Op: "^", Action: "xor", Left: left, Right: right}, nil)
+		l, lErr := manager.FromExpression(left)
+		r, rErr := manager.FromExpression(right)
+		if lErr == nil && rErr == nil {
+			if l == r {
+				return true, nil
+			}
+			difference, _ := manager.AnySat(manager.Xor(l, r))
+			return false, completeAssignment(idents, difference)
+		}
+	}
+
+	difference := &ast.InfixExpression{Op: "^", Action: "xor", Left: left, Right: right}
+	satisfiable, assignment := Satisfiable(difference)
+	return !satisfiable, assignment
+}
+
+// countModelsWithBDD counts the projected models of the expression using a
+// decision diagram, the second result is false when the diagram grew too big.
+func countModelsWithBDD(expression ast.Expression, projection []string) (*big.Int, bool) {
+	hidden := make([]string, 0)
+	for _, ident := range getAllIdentifiers(expression, nil) {
+		if !contains(projection, ident) {
+			hidden = append(hidden, ident)
+		}
+	}
+
+	manager := newDiagramManager(expression, projection)
+	f, err := manager.FromExpression(expression)
+	if err != nil {
+		return nil, false
+	}
+	count := manager.SatCount(manager.Exists(f, hidden...))
+	return count.Rsh(count, uint(len(hidden))), true
+}
+
+// completeAssignment assigns false to the identifiers missing from a partial
+// assignment.
+func completeAssignment(idents []string, partial map[string]bool) map[string]bool {
+	result := make(map[string]bool)
+	for _, ident := range idents {
+		result[ident] = partial[ident]
+	}
+	return result
+}
+
+func formatEquivalence(left ast.Expression, right ast.Expression, negated bool) string {
+	equivalent, difference := Equivalent(left, right)
+	if equivalent {
+		if negated {
+			return bold("false") + ", the expressions are equivalent"
+		}
+		return bold("true")
+	}
+
+	idents := getIdentifiersOfAll([]ast.Expression{left, right})
+	verdict := "false"
+	if negated {
+		verdict = "true"
+	}
+	return fmt.Sprintf("%s, the expressions differ under: %s", bold(verdict), formatAssignment(idents, difference))
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkEquivalent compares Equivalent with the truth tables of both sides
+// and checks that a reported difference is one.
+func checkEquivalent(t *testing.T, left, right ast.Expression, want bool) {
+	t.Helper()
+	equivalent, difference := Equivalent(left, right)
+	if equivalent != want {
+		t.Fatalf("%s == %s: %t, want %t", left.Literal(), right.Literal(), equivalent, want)
+	}
+	if !equivalent && evaluateExpression(difference, left) == evaluateExpression(difference, right) {
+		t.Fatalf("%s and %s agree under the reported difference %v", left.Literal(), right.Literal(), difference)
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	random := func() ast.Expression {
+		data := make([]byte, r.Intn(16))
+		r.Read(data)
+		return fuzzExpression(data)
+	}
+	for i := 0; i < 1000; i++ {
+		left, right := random(), random()
+		idents := getIdentifiersOfAll([]ast.Expression{left, right})
+		leftVector, _ := truthVector(left, idents)
+		rightVector, _ := truthVector(right, idents)
+		checkEquivalent(t, left, right, slices.Equal(leftVector, rightVector))
+	}
+}
+
+func TestEquivalentBySolver(t *testing.T) {
+	idents := make([]string, bddIdentifierLimit+1)
+	for i := range idents {
+		idents[i] = fmt.Sprintf("x%d", i)
+	}
+	reversed := slices.Clone(idents)
+	slices.Reverse(reversed)
+	changed := slices.Clone(idents)
+	changed[0] = "!" + changed[0]
+
+	disjunction := parseRuleSide(strings.Join(idents, " + "))
+	checkEquivalent(t, disjunction, parseRuleSide(strings.Join(reversed, " + ")), true)
+	checkEquivalent(t, disjunction, parseRuleSide(strings.Join(changed, " + ")), false)
+}
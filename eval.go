@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/terawatthour/logix/ast"
 	"math"
@@ -9,47 +10,146 @@ import (
 )
 
 type Evaluator struct {
-	simplificationRules []func(ast.Expression) (bool, ast.Expression)
+	simplificationRules []SimplificationRule
+
+	// trace records the rule applications of Simplify while Explain runs.
+	trace *Trace
+	// checked makes Simplify test every rewrite for equivalence, unsound
+	// records the first rewrite that failed.
+	checked bool
+	unsound *UnsoundRewriteError
+	// lookahead is set while the result of a guarded rule is simplified,
+	// guarded rules are not applied then.
+	lookahead bool
+	// run bounds the Simplify call in progress.
+	run *simplifyRun
+}
+
+// SimplifyOptions bound Simplify, a zero field means no bound. MaxSteps
+// counts rule applications and MaxSize the nodes of the formula in binary
+// form.
+type SimplifyOptions struct {
+	MaxSteps int
+	MaxSize  int
+}
+
+// StopReason tells why Simplify stopped.
+type StopReason string
+
+const (
+	Fixpoint  StopReason = "fixpoint"
+	StepLimit StopReason = "step limit"
+	SizeLimit StopReason = "size limit"
+	Cancelled StopReason = "cancelled"
+)
+
+type simplifyRun struct {
+	ctx     context.Context
+	options SimplifyOptions
+	steps   int
+	stop    StopReason
+	// best is the smallest formula seen so far, returned when the run
+	// stops before a fixpoint.
+	best     ast.Expression
+	bestCost int
 }
 
 func NewEvaluator() *Evaluator {
 	e := &Evaluator{
-		simplificationRules: make([]func(ast.Expression) (bool, ast.Expression), 0),
+		simplificationRules: make([]SimplificationRule, 0, len(builtinRules)),
 	}
 
-	e.registerSimplificationRule(IdentityRule)
-	e.registerSimplificationRule(IdempotenceRule)
-	e.registerSimplificationRule(NegatedAlternativeRule)
-	e.registerSimplificationRule(NegatedConjunctionRule)
-	e.registerSimplificationRule(ImplicationRule)
-	e.registerSimplificationRule(DeMorganRule)
-	e.registerSimplificationRule(DoubleNegationRule)
-	e.registerSimplificationRule(DuplicateAlternativeRule)
-	e.registerSimplificationRule(BiconditionalRule)
+	for _, rule := range builtinRules {
+		rule.Enabled = true
+		e.RegisterRule(rule)
+	}
 
 	return e
 }
 
-func (e *Evaluator) registerSimplificationRule(fn func(ast.Expression) (bool, ast.Expression)) {
-	e.simplificationRules = append(e.simplificationRules, fn)
-}
-
 func (e *Evaluator) evaluate(statement ast.Statement) string {
 	switch stmt := statement.(type) {
 	case *ast.TableStatement:
 		return generateTruthTable(stmt.Expression)
 	case *ast.SimplifyStatement:
-		return e.Simplify(stmt.Expression).Literal()
+		return e.formatSimplification(stmt)
+	case *ast.SatStatement:
+		return formatSatisfiability(stmt.Expression)
+	case *ast.ModelsStatement:
+		return formatModels(stmt.Expression, identifierValues(stmt.Projection))
+	case *ast.CountStatement:
+		return formatCount(stmt.Expression, identifierValues(stmt.Projection))
+	case *ast.ValidStatement:
+		return formatValidity(stmt.Expression)
+	case *ast.UnsatStatement:
+		return formatUnsatisfiability(stmt.Expression)
+	case *ast.ClassifyStatement:
+		return formatClassification(stmt.Expression)
+	case *ast.EntailmentStatement:
+		return formatEntailment(stmt.Premises, stmt.Conclusion)
+	case *ast.ConsistentStatement:
+		return formatConsistency(stmt.Formulas)
+	case *ast.ProveStatement:
+		return formatProof(stmt.Expression)
+	case *ast.TableauStatement:
+		return formatTableau(stmt.Expression, stmt.DOT)
+	case *ast.CoreStatement:
+		return formatUnsatCore(stmt.Formulas, stmt.Spans, stmt.Sources)
+	case *ast.EquivalenceStatement:
+		return formatEquivalence(stmt.Left, stmt.Right, stmt.Negated)
+	case *ast.BDDStatsStatement:
+		return formatDiagramStats(stmt.Expression)
+	case *ast.ExportStatement:
+		return formatExport(stmt.Format, stmt.Expression, stmt.Path)
+	case *ast.ImportStatement:
+		return formatImport(stmt.Format, stmt.Path)
+	case *ast.CheckStatement:
+		return formatDeduction(stmt.Path)
+	case *ast.DerivationStatement:
+		return e.formatDerivation(stmt.Steps, stmt.Laws)
+	case *ast.RuleStatement:
+		return e.formatRuleDefinition(stmt)
+	case *ast.LoadStatement:
+		return e.formatRuleFile(stmt.Path)
+	case *ast.SaturateStatement:
+		return e.formatSaturation(stmt.Expression, stmt.Cost)
+	case *ast.NNFStatement:
+		return NNF(stmt.Expression).Literal()
+	case *ast.ANFStatement:
+		return formatANF(stmt.Expression)
+	case *ast.UniversalStatement:
+		return formatUniversalForm(stmt.Expression, stmt.Gate, stmt.Shared)
+	case *ast.ClassesStatement:
+		return formatPostClasses(stmt.Expression)
+	case *ast.CompleteStatement:
+		return formatCompleteness(stmt.Connectives)
 	}
 
 	panic("implement me")
 }
 
-func (e *Evaluator) Simplify(expression ast.Expression) ast.Expression {
+// Simplify applies the simplification rules until the formula repeats, the
+// context is done or a bound of the options is hit. It returns the result
+// with the reason for stopping, the smallest formula seen on the way when it
+// stopped before a fixpoint. The rules see conjunctions and disjunctions
+// flattened into *ast.NaryExpression, the result is rebuilt in binary form.
+func (e *Evaluator) Simplify(ctx context.Context, expression ast.Expression, options SimplifyOptions) (ast.Expression, StopReason) {
+	flat := ast.Flatten(expression)
+	e.run = &simplifyRun{ctx: ctx, options: options, best: ast.Clone(flat), bestCost: expressionCost(flat)}
+	defer func() { e.run = nil }()
+
+	result := e.simplifyFlat(flat)
+	if e.run.stop != "" {
+		return ast.Binary(e.run.best), e.run.stop
+	}
+	return ast.Binary(result), Fixpoint
+}
+
+func (e *Evaluator) simplifyFlat(expression ast.Expression) ast.Expression {
 	was := make([]string, 0)
 	for {
-		expression = e.simplify(expression)
-		if contains(was, expression.Literal()) {
+		e.simplify(&expression, &expression)
+		if e.run.stop != "" || contains(was, expression.Literal()) {
 			break
 		}
 		was = append(was, expression.Literal())
@@ -57,29 +157,128 @@ func (e *Evaluator) Simplify(expression ast.Expression) ast.Expression {
 	return expression
 }
 
-func (e *Evaluator) simplify(expression ast.Expression) ast.Expression {
-	switch expr := expression.(type) {
+// Explain simplifies the expression like Simplify and returns the trace of
+// the rules applied on the way.
+func (e *Evaluator) Explain(ctx context.Context, expression ast.Expression, options SimplifyOptions) *Trace {
+	e.trace = &Trace{Input: ast.Clone(expression), Steps: make([]TraceStep, 0)}
+	defer func() { e.trace = nil }()
+
+	trace := e.trace
+	trace.Result, trace.Stop = e.Simplify(ctx, expression, options)
+	return trace
+}
+
+// exhausted reports whether the run has to stop before the next rule is
+// tried, recording the reason.
+func (e *Evaluator) exhausted() bool {
+	run := e.run
+	if run.stop != "" {
+		return true
+	}
+	if run.ctx.Err() != nil {
+		run.stop = Cancelled
+	} else if run.options.MaxSteps > 0 && run.steps >= run.options.MaxSteps {
+		run.stop = StepLimit
+	}
+	return run.stop != ""
+}
+
+// step counts a rewrite of the formula held by root, keeping the smallest
+// formula seen and stopping the run when the formula grows too large.
+func (e *Evaluator) step(root ast.Expression) {
+	run := e.run
+	run.steps++
+	if e.lookahead {
+		return
+	}
+	cost := expressionCost(root)
+	if cost < run.bestCost {
+		run.best, run.bestCost = ast.Clone(root), cost
+	}
+	if run.options.MaxSize > 0 && cost > run.options.MaxSize {
+		run.stop = SizeLimit
+	}
+}
+
+// simplify rewrites the expression held by slot bottom-up, storing every
+// replacement in place so that root always holds the whole current formula.
+func (e *Evaluator) simplify(root *ast.Expression, slot *ast.Expression) {
+	if e.run.stop != "" {
+		return
+	}
+	switch expr := (*slot).(type) {
 	case *ast.InfixExpression:
-		expr.Left = e.simplify(expr.Left)
-		expr.Right = e.simplify(expr.Right)
-		return e.applyRules(expr)
+		e.simplify(root, &expr.Left)
+		e.simplify(root, &expr.Right)
+		e.applyRules(root, slot)
+	case *ast.NaryExpression:
+		for i := range expr.Operands {
+			e.simplify(root, &expr.Operands[i])
+		}
+		// rewritten operands may need merging and sorting again
+		*slot = ast.NewNary(expr.Action, expr.Operands)
+		e.applyRules(root, slot)
 	case *ast.PrefixExpression:
-		expr.Right = e.simplify(expr.Right)
-		return e.applyRules(expr)
+		e.simplify(root, &expr.Right)
+		e.applyRules(root, slot)
 	case *ast.Identifier:
-		return expr
 	case *ast.Boolean:
-		return expr
+	default:
+		panic("unreachable")
 	}
-
-	panic("unreachable")
 }
 
-func (e *Evaluator) applyRules(expr ast.Expression) ast.Expression {
+func (e *Evaluator) applyRules(root *ast.Expression, slot *ast.Expression) {
 	for _, rule := range e.simplificationRules {
-		_, expr = rule(expr)
+		if !rule.Enabled || (rule.Guarded && e.lookahead) {
+			continue
+		}
+		if e.exhausted() {
+			return
+		}
+		matched, result := rule.Apply(*slot)
+		if matched {
+			result = ast.Flatten(result)
+		}
+		if matched && rule.Guarded {
+			result = e.simplifyAhead(result)
+			if e.run.stop != "" {
+				return
+			}
+			if expressionCost(result) >= expressionCost(*slot) {
+				continue
+			}
+		}
+		if matched && e.checked && !e.sound(rule, *slot, result) {
+			continue
+		}
+		if matched && e.trace != nil {
+			matchedExpression := ast.Binary(*slot)
+			*slot = result
+			e.trace.Steps = append(e.trace.Steps, TraceStep{
+				Rule:        rule.Name,
+				Matched:     matchedExpression,
+				Replacement: ast.Binary(result),
+				Formula:     ast.Binary(*root),
+			})
+			e.step(*root)
+			continue
+		}
+		*slot = result
+		if matched {
+			e.step(*root)
+		}
 	}
-	return expr
+}
+
+// simplifyAhead simplifies a copy of the result of a guarded rule without
+// guarded rules and without tracing.
+func (e *Evaluator) simplifyAhead(expression ast.Expression) ast.Expression {
+	trace := e.trace
+	e.trace, e.lookahead = nil, true
+	defer func() { e.trace, e.lookahead = trace, false }()
+
+	return e.simplifyFlat(ast.Clone(expression))
 }
 
 func evaluateExpression(input map[string]bool, expression ast.Expression) bool {
@@ -109,6 +308,8 @@ func evaluateInfixExpression(left bool, operator string, right bool) bool {
 		return !left || right
 	case "<->":
 		return (!left || right) && (!right || left)
+	case "^":
+		return left != right
 	}
 	return false
 }
@@ -230,6 +431,14 @@ func contains[T comparable](a []T, b T) bool {
 	return false
 }
 
+func identifierValues(identifiers []*ast.Identifier) []string {
+	values := make([]string, 0, len(identifiers))
+	for _, ident := range identifiers {
+		values = merge(values, []string{ident.Value})
+	}
+	return values
+}
+
 func getAllIdentifiers(expression ast.Expression, current []string) []string {
 	if current == nil {
 		current = make([]string, 0)
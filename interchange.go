@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/smtlib"
+	"io"
+	"os"
+	"strings"
+)
+
+func writeFormat(w io.Writer, format string, expression ast.Expression) error {
+	switch format {
+	case "dimacs":
+		return WriteDIMACS(w, expression)
+	case "smtlib":
+		_, err := io.WriteString(w, smtlib.Write(expression, getAllIdentifiers(expression, nil)))
+		return err
+	}
+	return fmt.Errorf("unknown format %s", format)
+}
+
+func readFormat(r io.Reader, format string) (ast.Expression, error) {
+	switch format {
+	case "dimacs":
+		return ReadDIMACS(r)
+	case "smtlib":
+		source, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		script, err := smtlib.Read(string(source))
+		if err != nil {
+			return nil, err
+		}
+		return script.Conjunction(), nil
+	}
+	return nil, fmt.Errorf("unknown format %s", format)
+}
+
+func formatExport(format string, expression ast.Expression, path string) string {
+	if path == "" {
+		var builder strings.Builder
+		if err := writeFormat(&builder, format, expression); err != nil {
+			return err.Error()
+		}
+		return strings.TrimSuffix(builder.String(), "\n")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer file.Close()
+	if err := writeFormat(file, format, expression); err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("written to %s", path)
+}
+
+func formatImport(format string, path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer file.Close()
+
+	expression, err := readFormat(file, format)
+	if err != nil {
+		return fmt.Sprintf("%s: %s", path, err)
+	}
+	return expression.Literal()
+}
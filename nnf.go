@@ -0,0 +1,82 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+)
+
+// dualOperators swap conjunction and disjunction keeping the spelling of
+// the operator.
+var dualOperators = map[string]string{
+	"+": "*",
+	"|": "&",
+	"*": "+",
+	"&": "|",
+}
+
+// NNF returns the negation normal form of the expression: ->, <-> and ^ are
+// eliminated and negations are pushed down until every PrefixExpression
+// wraps an identifier. Negated constants are folded, no other simplification
+// takes place.
+func NNF(expression ast.Expression) ast.Expression {
+	return nnf(expression, false)
+}
+
+// nnf rewrites the expression, or its negation when negated is set.
+func nnf(expression ast.Expression, negated bool) ast.Expression {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		if negated {
+			return &ast.PrefixExpression{Op: "!", Right: &ast.Identifier{Value: expr.Value}}
+		}
+		return &ast.Identifier{Value: expr.Value}
+	case *ast.Boolean:
+		return &ast.Boolean{Value: expr.Value != negated}
+	case *ast.PrefixExpression:
+		return nnf(expr.Right, !negated)
+	case *ast.NaryExpression:
+		return nnf(ast.Binary(expr), negated)
+	case *ast.InfixExpression:
+		switch expr.Action {
+		case "and", "or":
+			op, action := expr.Op, expr.Action
+			if negated {
+				op, action = dualOperators[op], dualAction(action)
+			}
+			return nnfInfix(op, action, nnf(expr.Left, negated), nnf(expr.Right, negated))
+		case "->":
+			// a -> b = !a + b, !(a -> b) = a * !b
+			if negated {
+				return nnfInfix("*", "and", nnf(expr.Left, false), nnf(expr.Right, true))
+			}
+			return nnfInfix("+", "or", nnf(expr.Left, true), nnf(expr.Right, false))
+		case "<->":
+			// a <-> b = (!a + b) * (a + !b), !(a <-> b) = a ^ b
+			if negated {
+				return nnfExclusive(expr.Left, expr.Right)
+			}
+			return nnfInfix("*", "and",
+				nnfInfix("+", "or", nnf(expr.Left, true), nnf(expr.Right, false)),
+				nnfInfix("+", "or", nnf(expr.Left, false), nnf(expr.Right, true)),
+			)
+		case "xor":
+			// !(a ^ b) = a <-> b
+			if negated {
+				return nnf(&ast.InfixExpression{Op: "<->", Action: "<->", Left: expr.Left, Right: expr.Right}, false)
+			}
+			return nnfExclusive(expr.Left, expr.Right)
+		}
+	}
+	panic("unreachable")
+}
+
+// nnfExclusive builds a ^ b = (a * !b) + (!a * b).
+func nnfExclusive(left ast.Expression, right ast.Expression) ast.Expression {
+	return nnfInfix("+", "or",
+		nnfInfix("*", "and", nnf(left, false), nnf(right, true)),
+		nnfInfix("*", "and", nnf(left, true), nnf(right, false)),
+	)
+}
+
+func nnfInfix(op string, action string, left ast.Expression, right ast.Expression) ast.Expression {
+	return &ast.InfixExpression{Op: op, Action: action, Left: left, Right: right}
+}
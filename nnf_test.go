@@ -0,0 +1,55 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"math/rand"
+	"testing"
+)
+
+// checkNegationNormal fails when the expression uses a connective other
+// than conjunction and disjunction or negates anything but an identifier.
+func checkNegationNormal(t *testing.T, input, expression ast.Expression) {
+	t.Helper()
+	switch expr := expression.(type) {
+	case *ast.Identifier, *ast.Boolean:
+	case *ast.PrefixExpression:
+		if _, ok := expr.Right.(*ast.Identifier); !ok {
+			t.Fatalf("the NNF of %s negates %s", input.Literal(), expr.Right.Literal())
+		}
+	case *ast.InfixExpression:
+		if expr.Action != "and" && expr.Action != "or" {
+			t.Fatalf("the NNF of %s uses %s", input.Literal(), expr.Op)
+		}
+		checkNegationNormal(t, input, expr.Left)
+		checkNegationNormal(t, input, expr.Right)
+	default:
+		t.Fatalf("the NNF of %s contains %s", input.Literal(), expression.Literal())
+	}
+}
+
+func TestNNF(t *testing.T) {
+	expressions := []ast.Expression{
+		parseRuleSide("!(a -> (b <-> !c))"),
+		parseRuleSide("!(a ^ b) * !!c"),
+		parseRuleSide("!(a & (b | !0))"),
+	}
+	r := rand.New(rand.NewSource(1))
+	for len(expressions) < 500 {
+		data := make([]byte, r.Intn(20))
+		r.Read(data)
+		expressions = append(expressions, fuzzExpression(data))
+	}
+
+	for _, expression := range expressions {
+		input := ast.Clone(expression)
+		result := NNF(expression)
+		checkNegationNormal(t, input, result)
+		if equivalent, _ := Equivalent(input, result); !equivalent {
+			t.Fatalf("%s has the inequivalent NNF %s", input.Literal(), result.Literal())
+		}
+	}
+
+	if got := NNF(parseRuleSide("!(a & b)")).Literal(); got != "(!a | !b)" {
+		t.Errorf("the NNF of !(a & b) is %s, want (!a | !b)", got)
+	}
+}
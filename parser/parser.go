@@ -3,28 +3,39 @@ package parser
 import (
 	"github.com/terawatthour/logix/ast"
 	"github.com/terawatthour/logix/tokenizer"
+	"strings"
 )
 
 type Precedence int
 
+// The connectives bind from the loosest, <->, to the tightest, negation,
+// so a + a * b is a + (a * b). Implication associates to the right, a -> b
+// -> c is a -> (b -> c), the other operators to the left.
 const (
 	_ Precedence = iota
 	LOWEST
-	INFIX
+	BICONDITION
+	IMPLICATION
+	OR
+	XOR
+	AND
 	PREFIX
 )
 
 var precedences = map[tokenizer.TokenKind]Precedence{
-	tokenizer.TOK_OR:          INFIX,
-	tokenizer.TOK_IMPLICATION: INFIX,
-	tokenizer.TOK_AND:         INFIX,
-	tokenizer.TOK_BICONDITION: INFIX,
+	tokenizer.TOK_BICONDITION: BICONDITION,
+	tokenizer.TOK_IMPLICATION: IMPLICATION,
+	tokenizer.TOK_OR:          OR,
+	tokenizer.TOK_XOR:         XOR,
+	tokenizer.TOK_AND:         AND,
 
 	tokenizer.TOK_BANG: PREFIX,
 
 	tokenizer.TOK_IDENT: LOWEST,
 }
 
+var rightAssociative = []tokenizer.TokenKind{tokenizer.TOK_IMPLICATION}
+
 type ParsingError struct {
 	Errors []string
 }
@@ -74,6 +85,7 @@ func NewParser(l *tokenizer.Tokenizer) *Parser {
 	p.registerPrefix(tokenizer.TOK_LPAREN, p.parseGroupExpression)
 
 	p.registerInfix(tokenizer.TOK_OR, p.parseInfixExpression)
+	p.registerInfix(tokenizer.TOK_XOR, p.parseInfixExpression)
 	p.registerInfix(tokenizer.TOK_IMPLICATION, p.parseInfixExpression)
 	p.registerInfix(tokenizer.TOK_BICONDITION, p.parseInfixExpression)
 	p.registerInfix(tokenizer.TOK_AND, p.parseInfixExpression)
@@ -87,14 +99,66 @@ func (p *Parser) Parse() (stmt ast.Statement, err error) {
 		return nil, NewParsingError(p.errors)
 	}
 	p.advanceToken()
+	if p.currentToken == nil {
+		p.errors = append(p.errors, "unexpected EOF")
+		return nil, NewParsingError(p.errors)
+	}
 
-	switch p.currentToken.Kind {
+	switch p.statementKind() {
 	case tokenizer.TOK_TABLE:
 		stmt = p.parseTableStatement()
 	case tokenizer.TOK_SIMPLIFY:
 		stmt = p.parseSimplifyStatement()
+	case tokenizer.TOK_SAT:
+		stmt = p.parseSatStatement()
+	case tokenizer.TOK_MODELS:
+		stmt = p.parseModelsStatement()
+	case tokenizer.TOK_COUNT:
+		stmt = p.parseCountStatement()
+	case tokenizer.TOK_VALID:
+		stmt = p.parseValidStatement()
+	case tokenizer.TOK_UNSAT:
+		stmt = p.parseUnsatStatement()
+	case tokenizer.TOK_CLASSIFY:
+		stmt = p.parseClassifyStatement()
+	case tokenizer.TOK_PREMISES:
+		stmt = p.parseEntailmentStatement()
+	case tokenizer.TOK_CONSISTENT:
+		stmt = p.parseConsistentStatement()
+	case tokenizer.TOK_CORE:
+		stmt = p.parseCoreStatement()
+	case tokenizer.TOK_PROVE:
+		stmt = p.parseProveStatement()
+	case tokenizer.TOK_TABLEAU:
+		stmt = p.parseTableauStatement()
+	case tokenizer.TOK_BDD:
+		stmt = p.parseBDDStatement()
+	case tokenizer.TOK_EXPORT:
+		stmt = p.parseExportStatement()
+	case tokenizer.TOK_IMPORT:
+		stmt = p.parseImportStatement()
+	case tokenizer.TOK_CHECK:
+		stmt = p.parseCheckStatement()
+	case tokenizer.TOK_DERIVE:
+		stmt = p.parseDerivationStatement()
+	case tokenizer.TOK_RULE:
+		stmt = p.parseRuleStatement()
+	case tokenizer.TOK_LOAD:
+		stmt = p.parseLoadStatement()
+	case tokenizer.TOK_SATURATE:
+		stmt = p.parseSaturateStatement()
+	case tokenizer.TOK_NNF:
+		stmt = p.parseNNFStatement()
+	case tokenizer.TOK_ANF:
+		stmt = p.parseANFStatement()
+	case tokenizer.TOK_NAND, tokenizer.TOK_NOR:
+		stmt = p.parseUniversalStatement()
+	case tokenizer.TOK_CLASSES:
+		stmt = p.parseClassesStatement()
+	case tokenizer.TOK_COMPLETE:
+		stmt = p.parseCompleteStatement()
 	default:
-		p.errors = append(p.errors, "unexpected token "+p.currentToken.Literal)
+		stmt = p.parseEquivalenceStatement()
 	}
 
 	if p.nextToken != nil {
@@ -108,32 +172,504 @@ func (p *Parser) Parse() (stmt ast.Statement, err error) {
 	return stmt, nil
 }
 
+// commands are the words leading a statement. The tokenizer does not reserve
+// them, so they remain usable as variables everywhere else.
+var commands = []tokenizer.TokenKind{
+	tokenizer.TOK_SAT,
+	tokenizer.TOK_MODELS,
+	tokenizer.TOK_COUNT,
+	tokenizer.TOK_VALID,
+	tokenizer.TOK_UNSAT,
+	tokenizer.TOK_CLASSIFY,
+	tokenizer.TOK_PREMISES,
+	tokenizer.TOK_CONSISTENT,
+	tokenizer.TOK_CORE,
+	tokenizer.TOK_PROVE,
+	tokenizer.TOK_TABLEAU,
+	tokenizer.TOK_BDD,
+	tokenizer.TOK_EXPORT,
+	tokenizer.TOK_IMPORT,
+	tokenizer.TOK_CHECK,
+	tokenizer.TOK_DERIVE,
+	tokenizer.TOK_RULE,
+	tokenizer.TOK_LOAD,
+	tokenizer.TOK_SATURATE,
+	tokenizer.TOK_NNF,
+	tokenizer.TOK_ANF,
+	tokenizer.TOK_NAND,
+	tokenizer.TOK_NOR,
+	tokenizer.TOK_CLASSES,
+	tokenizer.TOK_COMPLETE,
+}
+
+// statementKind returns the kind of the statement starting at the current
+// token. An identifier spelling a command leads that statement, unless a
+// comparison or an operator follows it, then it is a variable.
+func (p *Parser) statementKind() tokenizer.TokenKind {
+	word := tokenizer.TokenKind(p.currentToken.Literal)
+	if !p.currentIs(tokenizer.TOK_IDENT) || !tokenizer.Contains(commands, word) {
+		return p.currentToken.Kind
+	}
+	if !p.nextIsEnd() && (p.nextIs(tokenizer.TOK_EQ) || p.nextIs(tokenizer.TOK_NEQ) || p.infixParseFns[p.nextToken.Kind] != nil) {
+		return p.currentToken.Kind
+	}
+	return word
+}
+
+// parseSimplifyStatement parses "simplify <expression> [checked] [explain
+// [json|latex]]".
 func (p *Parser) parseSimplifyStatement() *ast.SimplifyStatement {
 	stmt := &ast.SimplifyStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	if !p.nextIsEnd() && p.nextIsWord(tokenizer.TOK_CHECKED) {
+		p.advanceToken()
+		stmt.Checked = true
+	}
+	if !p.nextIsEnd() && p.nextIsWord(tokenizer.TOK_EXPLAIN) {
+		p.advanceToken()
+		stmt.Explain = "text"
+		if !p.nextIsEnd() && (p.nextIsWord(tokenizer.TOK_JSON) || p.nextIsWord(tokenizer.TOK_LATEX)) {
+			p.advanceToken()
+			stmt.Explain = p.currentToken.Literal
+		}
+	}
+	return stmt
+}
+
+func (p *Parser) parseTableStatement() *ast.TableStatement {
+	stmt := &ast.TableStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseSatStatement() *ast.SatStatement {
+	stmt := &ast.SatStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseModelsStatement() *ast.ModelsStatement {
+	stmt := &ast.ModelsStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	stmt.Projection = p.parseProjection()
+	return stmt
+}
+
+func (p *Parser) parseCountStatement() *ast.CountStatement {
+	stmt := &ast.CountStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	stmt.Projection = p.parseProjection()
+	return stmt
+}
+
+func (p *Parser) parseValidStatement() *ast.ValidStatement {
+	stmt := &ast.ValidStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseUnsatStatement() *ast.UnsatStatement {
+	stmt := &ast.UnsatStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseClassifyStatement() *ast.ClassifyStatement {
+	stmt := &ast.ClassifyStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseBDDStatement() ast.Statement {
+	if !p.expectNextWord(tokenizer.TOK_STATS) {
+		return nil
+	}
+	stmt := &ast.BDDStatsStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+// parseExportStatement parses "export <format> <expression> [to <path>]".
+func (p *Parser) parseExportStatement() ast.Statement {
+	stmt := &ast.ExportStatement{Token: p.currentToken}
+	stmt.Format = p.parseFormat()
+	if stmt.Format == "" {
+		return nil
+	}
+	stmt.Expression = p.parseStatementExpression()
+
+	if !p.nextIsEnd() && p.nextIsWord(tokenizer.TOK_TO) {
+		p.advanceToken()
+		stmt.Path = p.parsePath()
+	}
+	return stmt
+}
+
+// parseImportStatement parses "import <format> <path>".
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := &ast.ImportStatement{Token: p.currentToken}
+	stmt.Format = p.parseFormat()
+	if stmt.Format == "" {
+		return nil
+	}
+	stmt.Path = p.parsePath()
+	return stmt
+}
+
+// parseCheckStatement parses "check <path>".
+func (p *Parser) parseCheckStatement() *ast.CheckStatement {
+	stmt := &ast.CheckStatement{Token: p.currentToken}
+	stmt.Path = p.parsePath()
+	return stmt
+}
+
+// parseDerivationStatement parses "derive <expression> = <expression> [by
+// <law>] = ...", a law name may consist of several words.
+func (p *Parser) parseDerivationStatement() ast.Statement {
+	stmt := &ast.DerivationStatement{Token: p.currentToken}
+	stmt.Steps = append(stmt.Steps, p.parseStatementExpression())
+	stmt.Laws = append(stmt.Laws, "")
+
+	for !p.nextIsEnd() {
+		if !p.expectNext(tokenizer.TOK_EQUALS) {
+			return nil
+		}
+		stmt.Steps = append(stmt.Steps, p.parseStatementExpression())
+
+		words := make([]string, 0)
+		if !p.nextIsEnd() && p.nextIsWord(tokenizer.TOK_BY) {
+			p.advanceToken()
+			for !p.nextIsEnd() && p.nextIs(tokenizer.TOK_IDENT) {
+				p.advanceToken()
+				words = append(words, p.currentToken.Literal)
+			}
+			if len(words) == 0 {
+				p.errors = append(p.errors, "expected a law name after by")
+				return nil
+			}
+		}
+		stmt.Laws = append(stmt.Laws, strings.Join(words, " "))
+	}
+
+	if len(stmt.Steps) < 2 {
+		p.errors = append(p.errors, "a derivation needs at least two expressions")
+		return nil
+	}
+	return stmt
+}
+
+// parseRuleStatement parses "rule <name>: <pattern> => <replacement>".
+func (p *Parser) parseRuleStatement() ast.Statement {
+	stmt := &ast.RuleStatement{Token: p.currentToken}
+	if !p.expectNext(tokenizer.TOK_IDENT) {
+		return nil
+	}
+	stmt.Name = p.currentToken.Literal
+	if !p.expectNext(tokenizer.TOK_COLON) {
+		return nil
+	}
+	stmt.Pattern = p.parseStatementExpression()
+	if !p.expectNext(tokenizer.TOK_REWRITE) {
+		return nil
+	}
+	stmt.Replacement = p.parseStatementExpression()
+	return stmt
+}
+
+// parseLoadStatement parses "load <path>".
+func (p *Parser) parseLoadStatement() *ast.LoadStatement {
+	stmt := &ast.LoadStatement{Token: p.currentToken}
+	stmt.Path = p.parsePath()
+	return stmt
+}
+
+func (p *Parser) parseNNFStatement() *ast.NNFStatement {
+	stmt := &ast.NNFStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseANFStatement() *ast.ANFStatement {
+	stmt := &ast.ANFStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseUniversalStatement() *ast.UniversalStatement {
+	stmt := &ast.UniversalStatement{Token: p.currentToken, Gate: p.currentToken.Literal}
+	stmt.Expression = p.parseStatementExpression()
+	if !p.nextIsEnd() && p.nextIsWord(tokenizer.TOK_SHARED) {
+		p.advanceToken()
+		stmt.Shared = true
+	}
+	return stmt
+}
+
+func (p *Parser) parseClassesStatement() *ast.ClassesStatement {
+	stmt := &ast.ClassesStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+// parseCompleteStatement parses "complete {<connective>, ...}".
+func (p *Parser) parseCompleteStatement() ast.Statement {
+	stmt := &ast.CompleteStatement{Token: p.currentToken, Connectives: make([]*ast.Connective, 0)}
+	if !p.expectNext(tokenizer.TOK_LBRACE) {
+		return nil
+	}
+	for !p.nextIsEnd() && !p.nextIs(tokenizer.TOK_RBRACE) {
+		stmt.Connectives = append(stmt.Connectives, p.parseConnective())
+		if p.nextIsEnd() || !p.nextIs(tokenizer.TOK_COMMA) {
+			break
+		}
+		p.advanceToken()
+	}
+	if !p.expectNext(tokenizer.TOK_RBRACE) {
+		return nil
+	}
+	return stmt
+}
+
+// connectiveOperators are the operators that may be named on their own in
+// the set of the complete statement.
+var connectiveOperators = []tokenizer.TokenKind{
+	tokenizer.TOK_AND,
+	tokenizer.TOK_OR,
+	tokenizer.TOK_XOR,
+	tokenizer.TOK_IMPLICATION,
+	tokenizer.TOK_BICONDITION,
+}
 
+// parseConnective parses an operator written on its own, "!", "nand" and
+// "nor" are one only when nothing else follows them, or else a formula.
+func (p *Parser) parseConnective() *ast.Connective {
+	named := p.nextIs(tokenizer.TOK_BANG) || p.nextIsWord(tokenizer.TOK_NAND) || p.nextIsWord(tokenizer.TOK_NOR)
+	if tokenizer.Contains(connectiveOperators, p.nextToken.Kind) || (named && p.connectiveEndsAt(p.i+2)) {
+		p.advanceToken()
+		return &ast.Connective{Operator: p.currentToken.Literal}
+	}
+	return &ast.Connective{Expression: p.parseStatementExpression()}
+}
+
+// connectiveEndsAt reports whether the token at the index closes a member of
+// the set.
+func (p *Parser) connectiveEndsAt(index int) bool {
+	if index >= len(p.l.Tokens) {
+		return true
+	}
+	kind := p.l.Tokens[index].Kind
+	return kind == tokenizer.TOK_COMMA || kind == tokenizer.TOK_RBRACE
+}
+
+// parseSaturateStatement parses "saturate <expression> [by <cost model>]".
+func (p *Parser) parseSaturateStatement() ast.Statement {
+	stmt := &ast.SaturateStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	if !p.nextIsEnd() && p.nextIsWord(tokenizer.TOK_BY) {
+		p.advanceToken()
+		if p.nextIsEnd() || !p.nextIs(tokenizer.TOK_IDENT) {
+			p.errors = append(p.errors, "expected a cost model after by")
+			return nil
+		}
+		p.advanceToken()
+		stmt.Cost = p.currentToken.Literal
+	}
+	return stmt
+}
+
+// parseFormat reads the interchange format named after import or export.
+func (p *Parser) parseFormat() string {
+	if !p.nextIsEnd() && (p.nextIsWord(tokenizer.TOK_DIMACS) || p.nextIsWord(tokenizer.TOK_SMTLIB)) {
+		p.advanceToken()
+		return p.currentToken.Literal
+	}
+	p.errors = append(p.errors, "expected dimacs or smtlib")
+	return ""
+}
+
+// parsePath takes the raw source text after the current token as a file
+// path, paths are not tokenized so they may contain any character. A pair
+// of surrounding double quotes is removed.
+func (p *Parser) parsePath() string {
 	if p.nextIsEnd() {
-		p.errors = append(p.errors, "unexpected EOF")
-	} else {
+		p.errors = append(p.errors, "expected a path, got EOF")
+		return ""
+	}
+	path := strings.TrimSpace(string(p.l.Runes[p.nextToken.Start:]))
+	if len(path) >= 2 && strings.HasPrefix(path, "\"") && strings.HasSuffix(path, "\"") {
+		path = path[1 : len(path)-1]
+	}
+	for !p.nextIsEnd() {
 		p.advanceToken()
-		stmt.Expression = p.parseExpression(LOWEST)
 	}
+	return path
+}
+
+// parseEquivalenceStatement parses a comparison "left == right" or
+// "left != right", it is the only statement without a leading keyword.
+func (p *Parser) parseEquivalenceStatement() *ast.EquivalenceStatement {
+	stmt := &ast.EquivalenceStatement{Token: p.currentToken}
+	stmt.Left = p.parseExpression(LOWEST)
+
+	if p.currentToken == nil {
+		// the input ended inside the expression
+		if len(p.errors) == 0 {
+			p.errors = append(p.errors, "unexpected EOF")
+		}
+		return stmt
+	}
+	if p.nextIsEnd() || (!p.nextIs(tokenizer.TOK_EQ) && !p.nextIs(tokenizer.TOK_NEQ)) {
+		p.errors = append(p.errors, "unexpected token "+p.currentToken.Literal)
+		return stmt
+	}
+	p.advanceToken()
+	stmt.Token = p.currentToken
+	stmt.Negated = p.currentIs(tokenizer.TOK_NEQ)
+	stmt.Right = p.parseStatementExpression()
 
 	return stmt
 }
 
-func (p *Parser) parseTableStatement() *ast.TableStatement {
-	stmt := &ast.TableStatement{Token: p.currentToken}
+// parseEntailmentStatement parses "premises p1, p2, ... |- conclusion", the
+// turnstile may also be written as "entails" and the premises may be empty.
+func (p *Parser) parseEntailmentStatement() *ast.EntailmentStatement {
+	stmt := &ast.EntailmentStatement{Token: p.currentToken, Premises: make([]ast.Expression, 0)}
+
+	if !p.nextIsEnd() && !p.nextIs(tokenizer.TOK_TURNSTILE) && !p.nextIsWord(tokenizer.TOK_ENTAILS) {
+		stmt.Premises = p.parseExpressionList()
+	}
+
+	if p.nextIsEnd() || (!p.nextIs(tokenizer.TOK_TURNSTILE) && !p.nextIsWord(tokenizer.TOK_ENTAILS)) {
+		p.errors = append(p.errors, "expected |- or entails after the premises")
+		return stmt
+	}
+	p.advanceToken()
+	stmt.Conclusion = p.parseStatementExpression()
+
+	return stmt
+}
 
+func (p *Parser) parseProveStatement() *ast.ProveStatement {
+	stmt := &ast.ProveStatement{Token: p.currentToken}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+// parseTableauStatement parses "tableau [dot] <expression>", the format is
+// named in front of the expression like in the export statement. A dot
+// followed by an operator is a variable.
+func (p *Parser) parseTableauStatement() *ast.TableauStatement {
+	stmt := &ast.TableauStatement{Token: p.currentToken}
+	if !p.nextIsEnd() && p.nextIsWord(tokenizer.TOK_DOT) && p.i+2 < len(p.l.Tokens) && p.infixParseFns[p.l.Tokens[p.i+2].Kind] == nil {
+		p.advanceToken()
+		stmt.DOT = true
+	}
+	stmt.Expression = p.parseStatementExpression()
+	return stmt
+}
+
+func (p *Parser) parseCoreStatement() *ast.CoreStatement {
+	stmt := &ast.CoreStatement{Token: p.currentToken}
 	if p.nextIsEnd() {
 		p.errors = append(p.errors, "unexpected EOF")
-	} else {
-		p.advanceToken()
-		stmt.Expression = p.parseExpression(LOWEST)
+		return stmt
+	}
+	stmt.Formulas, stmt.Spans = p.parseExpressionListWithSpans()
+	for _, span := range stmt.Spans {
+		stmt.Sources = append(stmt.Sources, string(p.l.Runes[span.Start:span.End]))
 	}
+	return stmt
+}
 
+func (p *Parser) parseConsistentStatement() *ast.ConsistentStatement {
+	stmt := &ast.ConsistentStatement{Token: p.currentToken}
+	if p.nextIsEnd() {
+		p.errors = append(p.errors, "unexpected EOF")
+		return stmt
+	}
+	stmt.Formulas = p.parseExpressionList()
 	return stmt
 }
 
+// parseExpressionList parses comma separated expressions following the
+// current token.
+func (p *Parser) parseExpressionList() []ast.Expression {
+	expressions, _ := p.parseExpressionListWithSpans()
+	return expressions
+}
+
+// parseExpressionListWithSpans parses an expression list like
+// parseExpressionList and also reports where in the source each
+// expression was written.
+func (p *Parser) parseExpressionListWithSpans() ([]ast.Expression, []ast.Span) {
+	expressions := make([]ast.Expression, 0)
+	spans := make([]ast.Span, 0)
+	for {
+		span := ast.Span{}
+		if !p.nextIsEnd() {
+			span.Start = p.nextToken.Start
+		}
+		expressions = append(expressions, p.parseStatementExpression())
+		if p.currentToken != nil {
+			span.End = p.currentToken.Start + p.currentToken.Length
+		}
+		spans = append(spans, span)
+
+		if p.nextIsEnd() || !p.nextIs(tokenizer.TOK_COMMA) {
+			return expressions, spans
+		}
+		p.advanceToken()
+	}
+}
+
+// parseProjection parses an optional "over a, b, ..." clause.
+func (p *Parser) parseProjection() []*ast.Identifier {
+	if p.nextIsEnd() || !p.nextIsWord(tokenizer.TOK_OVER) {
+		return nil
+	}
+	p.advanceToken()
+
+	projection := make([]*ast.Identifier, 0)
+	for {
+		if !p.expectNext(tokenizer.TOK_IDENT) {
+			return nil
+		}
+		projection = append(projection, p.parseIdentifier().(*ast.Identifier))
+		if p.nextIsEnd() || !p.nextIs(tokenizer.TOK_COMMA) {
+			return projection
+		}
+		p.advanceToken()
+	}
+}
+
+// ParseExpression parses the whole input as a single expression, without a
+// statement keyword in front of it.
+func (p *Parser) ParseExpression() (ast.Expression, error) {
+	if !p.l.IsTokenized {
+		p.errors = append(p.errors, "tokenizer must be tokenized before parsing")
+		return nil, NewParsingError(p.errors)
+	}
+	p.advanceToken()
+	expression := p.parseExpression(LOWEST)
+	if p.nextToken != nil {
+		p.errors = append(p.errors, "unexpected token "+p.nextToken.Literal)
+	}
+	if len(p.errors) > 0 {
+		return nil, NewParsingError(p.errors)
+	}
+	return expression, nil
+}
+
+// parseStatementExpression parses the expression following a statement keyword.
+func (p *Parser) parseStatementExpression() ast.Expression {
+	if p.nextIsEnd() {
+		p.errors = append(p.errors, "unexpected EOF")
+		return nil
+	}
+	p.advanceToken()
+	return p.parseExpression(LOWEST)
+}
+
 func (p *Parser) parseExpression(precedence Precedence) ast.Expression {
 	if p.currentToken == nil {
 		p.errors = append(p.errors, "unexpected EOF")
@@ -192,6 +728,10 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:   left,
 	}
 	precedence := p.currentPrecedence()
+	if tokenizer.Contains(rightAssociative, p.currentToken.Kind) {
+		// the right operand may hold the same operator again
+		precedence--
+	}
 	p.advanceToken()
 	expr.Right = p.parseExpression(precedence)
 	return expr
@@ -239,11 +779,21 @@ func (p *Parser) nextIs(t tokenizer.TokenKind) bool {
 	return p.nextToken.Kind == t
 }
 
+// nextIsWord reports whether the next token is an identifier spelling the
+// word, the words of the statements are not reserved by the tokenizer.
+func (p *Parser) nextIsWord(word tokenizer.TokenKind) bool {
+	return p.nextIs(tokenizer.TOK_IDENT) && p.nextToken.Literal == string(word)
+}
+
 func (p *Parser) nextIsEnd() bool {
 	return p.nextToken == nil
 }
 
 func (p *Parser) expectNext(kind tokenizer.TokenKind) bool {
+	if p.nextToken == nil {
+		p.errors = append(p.errors, "expected "+string(kind)+", got EOF")
+		return false
+	}
 	if p.nextToken.Kind == kind {
 		p.advanceToken()
 		return true
@@ -252,6 +802,19 @@ func (p *Parser) expectNext(kind tokenizer.TokenKind) bool {
 	return false
 }
 
+func (p *Parser) expectNextWord(word tokenizer.TokenKind) bool {
+	if p.nextToken == nil {
+		p.errors = append(p.errors, "expected "+string(word)+", got EOF")
+		return false
+	}
+	if p.nextIsWord(word) {
+		p.advanceToken()
+		return true
+	}
+	p.errors = append(p.errors, "expected "+string(word)+", got "+p.nextToken.Literal)
+	return false
+}
+
 func (p *Parser) advanceToken() {
 	p.i++
 	if p.i >= len(p.l.Tokens) {
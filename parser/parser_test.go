@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/tokenizer"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, source string) ast.Statement {
+	t.Helper()
+	tok := tokenizer.NewTokenizer(source)
+	if err := tok.Tokenize(); err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	stmt, err := NewParser(tok).Parse()
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	return stmt
+}
+
+func TestSaturateByNand(t *testing.T) {
+	stmt, ok := parse(t, "saturate a * b by nand").(*ast.SaturateStatement)
+	if !ok {
+		t.Fatal("expected a saturate statement")
+	}
+	if stmt.Cost != "nand" {
+		t.Errorf("cost model %q, want nand", stmt.Cost)
+	}
+	if got := stmt.Expression.Literal(); got != "(a * b)" {
+		t.Errorf("expression %s, want (a * b)", got)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	cases := map[string]string{
+		"a + a * b":           "(a + (a * b))",
+		"a * b + c":           "((a * b) + c)",
+		"a + b ^ c * d":       "(a + (b ^ (c * d)))",
+		"a -> b + c <-> d":    "((a -> (b + c)) <-> d)",
+		"!a * b":              "(!a * b)",
+		"a + b + c":           "((a + b) + c)",
+		"a -> b -> c":         "(a -> (b -> c))",
+		"a -> b -> c -> d":    "(a -> (b -> (c -> d)))",
+		"(a -> b) -> c":       "((a -> b) -> c)",
+		"a -> b <-> c -> d":   "((a -> b) <-> (c -> d))",
+		"a <-> b <-> c":       "((a <-> b) <-> c)",
+		"a * b -> c + d -> e": "((a * b) -> ((c + d) -> e))",
+		"(a + b) * c & d | e": "((((a + b) * c) & d) | e)",
+	}
+	for source, want := range cases {
+		tok := tokenizer.NewTokenizer(source)
+		if err := tok.Tokenize(); err != nil {
+			t.Fatal(err)
+		}
+		expression, err := NewParser(tok).ParseExpression()
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+		if got := expression.Literal(); got != want {
+			t.Errorf("%s parsed as %s, want %s", source, got, want)
+		}
+	}
+}
+
+func TestCommandWordsAsVariables(t *testing.T) {
+	cases := map[string]string{
+		"to + by == by + to":    "(to + by)",
+		"count * over != count": "(count * over)",
+		"sat -> dot == 1":       "(sat -> dot)",
+	}
+	for source, want := range cases {
+		stmt, ok := parse(t, source).(*ast.EquivalenceStatement)
+		if !ok {
+			t.Fatalf("%s: expected a comparison", source)
+		}
+		if got := stmt.Left.Literal(); got != want {
+			t.Errorf("%s: left side %s, want %s", source, got, want)
+		}
+	}
+
+	stmt, ok := parse(t, "count core + rule over core").(*ast.CountStatement)
+	if !ok {
+		t.Fatal("expected a count statement")
+	}
+	if got := stmt.Expression.Literal(); got != "(core + rule)" {
+		t.Errorf("expression %s, want (core + rule)", got)
+	}
+	if len(stmt.Projection) != 1 || stmt.Projection[0].Value != "core" {
+		t.Errorf("projection %v, want core", stmt.Projection)
+	}
+}
+
+func TestTableauFormat(t *testing.T) {
+	cases := []struct {
+		source     string
+		dot        bool
+		expression string
+	}{
+		{"tableau a -> a", false, "(a -> a)"},
+		{"tableau dot a -> a", true, "(a -> a)"},
+		{"tableau dot !(a * !a)", true, "!(a * !a)"},
+		{"tableau dot", false, "dot"},
+		{"tableau dot -> dot", false, "(dot -> dot)"},
+	}
+	for _, c := range cases {
+		stmt, ok := parse(t, c.source).(*ast.TableauStatement)
+		if !ok {
+			t.Fatalf("%s: expected a tableau statement", c.source)
+		}
+		if stmt.DOT != c.dot {
+			t.Errorf("%s: dot %t, want %t", c.source, stmt.DOT, c.dot)
+		}
+		if got := stmt.Expression.Literal(); got != c.expression {
+			t.Errorf("%s: expression %s, want %s", c.source, got, c.expression)
+		}
+	}
+}
+
+func TestUnexpectedEOF(t *testing.T) {
+	for _, source := range []string{"!", "a + !", "a == !", "sat a * !"} {
+		tok := tokenizer.NewTokenizer(source)
+		if err := tok.Tokenize(); err != nil {
+			t.Fatal(err)
+		}
+		_, err := NewParser(tok).Parse()
+		if err == nil || !strings.Contains(err.Error(), "unexpected EOF") {
+			t.Errorf("%s: error %v, want unexpected EOF", source, err)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"math/bits"
+	"strings"
+)
+
+// PostClass is one of the five maximal classes of Post's lattice. A set of
+// connectives is functionally complete exactly when no class contains all
+// of them.
+type PostClass int
+
+const (
+	ZeroPreserving PostClass = iota
+	OnePreserving
+	SelfDual
+	Monotone
+	Linear
+)
+
+var postClasses = []PostClass{ZeroPreserving, OnePreserving, SelfDual, Monotone, Linear}
+
+func (c PostClass) String() string {
+	switch c {
+	case ZeroPreserving:
+		return "0-preserving"
+	case OnePreserving:
+		return "1-preserving"
+	case SelfDual:
+		return "self-dual"
+	case Monotone:
+		return "monotone"
+	}
+	return "linear"
+}
+
+// connectiveDefinitions give the operators that may be named on their own
+// in the complete statement as formulas over a and b.
+var connectiveDefinitions = map[string]string{
+	"*":    "a * b",
+	"&":    "a & b",
+	"+":    "a + b",
+	"|":    "a | b",
+	"^":    "a ^ b",
+	"->":   "a -> b",
+	"<->":  "a <-> b",
+	"!":    "!a",
+	"nand": "!(a * b)",
+	"nor":  "!(a + b)",
+}
+
+// booleanFunction is the function computed by a formula, its arguments are
+// the identifiers and bit i of an index into the truth vector is the value
+// of idents[i].
+type booleanFunction struct {
+	idents []string
+	vector []bool
+}
+
+func newBooleanFunction(expression ast.Expression) (*booleanFunction, error) {
+	idents := getAllIdentifiers(expression, nil)
+	vector, err := truthVector(expression, idents)
+	if err != nil {
+		return nil, err
+	}
+	return &booleanFunction{idents: idents, vector: vector}, nil
+}
+
+// selfDualWitness returns an index at which the function takes the same
+// value as at the complemented index, or -1 when it is self-dual.
+func (f *booleanFunction) selfDualWitness() int {
+	mask := len(f.vector) - 1
+	for index, value := range f.vector {
+		if value == f.vector[index^mask] {
+			return index
+		}
+	}
+	return -1
+}
+
+// monotoneWitness returns two indices differing in one argument, raised in
+// the second, at which the function falls from true to false, or -1 and -1
+// when it is monotone.
+func (f *booleanFunction) monotoneWitness() (int, int) {
+	for index, value := range f.vector {
+		if !value {
+			continue
+		}
+		for i := range f.idents {
+			raised := index | 1<<i
+			if raised != index && !f.vector[raised] {
+				return index, raised
+			}
+		}
+	}
+	return -1, -1
+}
+
+// degree returns the algebraic degree of the function, it is linear when
+// the degree is at most 1.
+func (f *booleanFunction) degree() int {
+	coefficients := make([]bool, len(f.vector))
+	copy(coefficients, f.vector)
+	mobius(coefficients, len(f.idents))
+	degree := 0
+	for index, coefficient := range coefficients {
+		if coefficient {
+			degree = max(degree, bits.OnesCount(uint(index)))
+		}
+	}
+	return degree
+}
+
+// in reports whether the function belongs to the class.
+func (f *booleanFunction) in(class PostClass) bool {
+	switch class {
+	case ZeroPreserving:
+		return !f.vector[0]
+	case OnePreserving:
+		return f.vector[len(f.vector)-1]
+	case SelfDual:
+		return f.selfDualWitness() < 0
+	case Monotone:
+		index, _ := f.monotoneWitness()
+		return index < 0
+	}
+	return f.degree() <= 1
+}
+
+func (f *booleanFunction) assignment(index int) string {
+	values := make(map[string]bool, len(f.idents))
+	for i, ident := range f.idents {
+		values[ident] = index&(1<<i) != 0
+	}
+	return formatAssignment(f.idents, values)
+}
+
+// explain tells whether the function belongs to the class and, when it does
+// not, gives the assignments that show it.
+func (f *booleanFunction) explain(class PostClass) string {
+	if f.in(class) {
+		return "yes"
+	}
+	switch class {
+	case ZeroPreserving:
+		return fmt.Sprintf("no, true under %s", f.assignment(0))
+	case OnePreserving:
+		return fmt.Sprintf("no, false under %s", f.assignment(len(f.vector)-1))
+	case SelfDual:
+		index := f.selfDualWitness()
+		return fmt.Sprintf("no, %t under %s and under %s", f.vector[index], f.assignment(index), f.assignment(index^(len(f.vector)-1)))
+	case Monotone:
+		index, raised := f.monotoneWitness()
+		return fmt.Sprintf("no, true under %s and false under %s", f.assignment(index), f.assignment(raised))
+	}
+	return fmt.Sprintf("no, algebraic degree %d", f.degree())
+}
+
+// ConnectiveClasses returns the Post classes the function computed by the
+// expression belongs to.
+func ConnectiveClasses(expression ast.Expression) ([]PostClass, error) {
+	f, err := newBooleanFunction(expression)
+	if err != nil {
+		return nil, err
+	}
+	classes := make([]PostClass, 0, len(postClasses))
+	for _, class := range postClasses {
+		if f.in(class) {
+			classes = append(classes, class)
+		}
+	}
+	return classes, nil
+}
+
+// FunctionallyComplete decides by Post's criterion whether every Boolean
+// function can be built from the connectives, each given as a formula whose
+// identifiers are its arguments. It also returns the classes containing
+// every connective, the set is complete when there are none.
+func FunctionallyComplete(connectives []ast.Expression) (bool, []PostClass, error) {
+	memberships := make([][]PostClass, 0, len(connectives))
+	for _, connective := range connectives {
+		classes, err := ConnectiveClasses(connective)
+		if err != nil {
+			return false, nil, err
+		}
+		memberships = append(memberships, classes)
+	}
+	shared := sharedClasses(memberships)
+	return len(shared) == 0, shared, nil
+}
+
+// sharedClasses returns the classes present in every membership list.
+func sharedClasses(memberships [][]PostClass) []PostClass {
+	shared := make([]PostClass, 0, len(postClasses))
+	for _, class := range postClasses {
+		everywhere := true
+		for _, classes := range memberships {
+			everywhere = everywhere && contains(classes, class)
+		}
+		if everywhere {
+			shared = append(shared, class)
+		}
+	}
+	return shared
+}
+
+// connectiveExpression returns the formula defining the connective.
+func connectiveExpression(connective *ast.Connective) ast.Expression {
+	if connective.Expression != nil {
+		return connective.Expression
+	}
+	return parseRuleSide(connectiveDefinitions[connective.Operator])
+}
+
+func formatPostClasses(expression ast.Expression) string {
+	f, err := newBooleanFunction(expression)
+	if err != nil {
+		return err.Error()
+	}
+	lines := make([]string, 0, len(postClasses))
+	for _, class := range postClasses {
+		lines = append(lines, fmt.Sprintf("%s  %s", bold(fmt.Sprintf("%-12s", class)), f.explain(class)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatCompleteness(connectives []*ast.Connective) string {
+	width := 0
+	for _, connective := range connectives {
+		width = max(width, len(connective.Literal()))
+	}
+
+	header := strings.Repeat(" ", width)
+	for _, class := range postClasses {
+		header += "  " + class.String()
+	}
+	lines := []string{bold(header)}
+	memberships := make([][]PostClass, 0, len(connectives))
+	for _, connective := range connectives {
+		classes, err := ConnectiveClasses(connectiveExpression(connective))
+		if err != nil {
+			return fmt.Sprintf("%s: %s", connective.Literal(), err)
+		}
+		memberships = append(memberships, classes)
+
+		line := fmt.Sprintf("%-*s", width, connective.Literal())
+		for _, class := range postClasses {
+			mark := "\033[31m✗\033[0m"
+			if contains(classes, class) {
+				mark = "\033[32m✓\033[0m"
+			}
+			line += "  " + mark + strings.Repeat(" ", len(class.String())-1)
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+
+	shared := sharedClasses(memberships)
+	switch {
+	case len(connectives) == 0:
+		lines = append(lines, bold("not functionally complete")+", the set is empty")
+	case len(shared) == 0:
+		lines = append(lines, bold("functionally complete"))
+	default:
+		names := make([]string, 0, len(shared))
+		for _, class := range shared {
+			names = append(names, class.String())
+		}
+		lines = append(lines, bold("not functionally complete")+", every connective is "+strings.Join(names, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// bruteForceClasses decides the Post classes from their definitions over
+// the truth vector and the ANF.
+func bruteForceClasses(t *testing.T, expression ast.Expression) []PostClass {
+	t.Helper()
+	idents := getAllIdentifiers(expression, nil)
+	vector, _ := truthVector(expression, idents)
+	mask := len(vector) - 1
+	selfDual, monotone := true, true
+	for x := range vector {
+		selfDual = selfDual && vector[x] != vector[x^mask]
+		for y := range vector {
+			// x is below y when every argument raised in x is raised in y
+			if x&y == x && vector[x] && !vector[y] {
+				monotone = false
+			}
+		}
+	}
+	_, degree, err := ANF(expression)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	classes := make([]PostClass, 0, len(postClasses))
+	for class, member := range map[PostClass]bool{
+		ZeroPreserving: !vector[0],
+		OnePreserving:  vector[mask],
+		SelfDual:       selfDual,
+		Monotone:       monotone,
+		Linear:         degree <= 1,
+	} {
+		if member {
+			classes = append(classes, class)
+		}
+	}
+	slices.Sort(classes)
+	return classes
+}
+
+func TestConnectiveClasses(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		data := make([]byte, r.Intn(16))
+		r.Read(data)
+		expression := fuzzExpression(data)
+		classes, err := ConnectiveClasses(expression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := bruteForceClasses(t, expression); !slices.Equal(classes, want) {
+			t.Fatalf("%s is in %v, want %v", expression.Literal(), classes, want)
+		}
+	}
+}
+
+func TestFunctionallyComplete(t *testing.T) {
+	cases := []struct {
+		connectives []string
+		complete    bool
+		shared      []PostClass
+	}{
+		{[]string{"nand"}, true, nil},
+		{[]string{"nor"}, true, nil},
+		{[]string{"*", "+"}, false, []PostClass{ZeroPreserving, OnePreserving, Monotone}},
+		{[]string{"*", "!"}, true, nil},
+		{[]string{"->"}, false, []PostClass{OnePreserving}},
+		{[]string{"^", "<->"}, false, []PostClass{Linear}},
+		{[]string{"!"}, false, []PostClass{SelfDual, Linear}},
+	}
+	for _, c := range cases {
+		expressions := make([]ast.Expression, 0, len(c.connectives))
+		for _, connective := range c.connectives {
+			expressions = append(expressions, connectiveExpression(&ast.Connective{Operator: connective}))
+		}
+		complete, shared, err := FunctionallyComplete(expressions)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if complete != c.complete || !slices.Equal(shared, c.shared) {
+			t.Errorf("{%s} is complete %t and shares %v, want %t and %v", strings.Join(c.connectives, ", "), complete, shared, c.complete, c.shared)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/resolution"
+	"github.com/terawatthour/logix/sat"
+	"strings"
+)
+
+// proofClauseLimit bounds the clauses obtained by distributing a formula into
+// conjunctive normal form for a proof, bigger formulas are Tseitin encoded.
+const proofClauseLimit = 256
+
+// resolutionLimit bounds the clauses derived while searching for a refutation.
+const resolutionLimit = 20000
+
+// proofClauses converts the expression into clauses, returning the name of
+// every variable. Auxiliary variables of a Tseitin encoding are named _t<n>.
+func proofClauses(expression ast.Expression) ([][]int, []string) {
+	idents := getAllIdentifiers(expression, nil)
+	variables := make(map[string]int)
+	for i, ident := range idents {
+		variables[ident] = i + 1
+	}
+
+	if clauses, ok := distributeCNF(expression, true, variables); ok {
+		return clauses, idents
+	}
+
+	encoding := newCNFEncoding(sat.NewSolver(), idents)
+	encoding.assert(expression)
+	names := append([]string{}, idents...)
+	for v := len(idents) + 1; v <= encoding.solver.NumVars(); v++ {
+		names = append(names, fmt.Sprintf("_t%d", v))
+	}
+	return encoding.clauses, names
+}
+
+// distributeCNF returns the clauses of the expression, or of its negation
+// when positive is false, by pushing negations inward and distributing
+// disjunctions over conjunctions. It gives up past proofClauseLimit clauses.
+func distributeCNF(expression ast.Expression, positive bool, variables map[string]int) ([][]int, bool) {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		if positive {
+			return [][]int{{variables[expr.Value]}}, true
+		}
+		return [][]int{{-variables[expr.Value]}}, true
+	case *ast.Boolean:
+		if expr.Value == positive {
+			return [][]int{}, true
+		}
+		return [][]int{{}}, true
+	case *ast.PrefixExpression:
+		return distributeCNF(expr.Right, !positive, variables)
+	case *ast.InfixExpression:
+		both := func(leftPositive bool, rightPositive bool, conjunction bool) ([][]int, bool) {
+			left, ok := distributeCNF(expr.Left, leftPositive, variables)
+			if !ok {
+				return nil, false
+			}
+			right, ok := distributeCNF(expr.Right, rightPositive, variables)
+			if !ok {
+				return nil, false
+			}
+			if conjunction {
+				return limitClauses(append(left, right...))
+			}
+			return clauseProduct(left, right)
+		}
+		union := func(a [][]int, aOk bool, b [][]int, bOk bool) ([][]int, bool) {
+			if !aOk || !bOk {
+				return nil, false
+			}
+			return limitClauses(append(a, b...))
+		}
+
+		switch expr.Op {
+		case "*", "&":
+			return both(positive, positive, positive)
+		case "+", "|":
+			return both(positive, positive, !positive)
+		case "->":
+			return both(!positive, positive, !positive)
+		case "<->", "^":
+			equivalence := (expr.Op == "<->") == positive
+			if equivalence {
+				a, aOk := both(false, true, false)
+				b, bOk := both(true, false, false)
+				return union(a, aOk, b, bOk)
+			}
+			a, aOk := both(true, true, false)
+			b, bOk := both(false, false, false)
+			return union(a, aOk, b, bOk)
+		}
+	}
+
+	panic("unreachable")
+}
+
+// clauseProduct returns the clauses of the disjunction of two clause sets.
+func clauseProduct(left [][]int, right [][]int) ([][]int, bool) {
+	if len(left)*len(right) > proofClauseLimit {
+		return nil, false
+	}
+	result := make([][]int, 0, len(left)*len(right))
+	for _, l := range left {
+	outer:
+		for _, r := range right {
+			clause := append([]int{}, l...)
+			for _, lit := range r {
+				if contains(clause, -lit) {
+					continue outer
+				}
+				if !contains(clause, lit) {
+					clause = append(clause, lit)
+				}
+			}
+			result = append(result, clause)
+		}
+	}
+	return result, true
+}
+
+func limitClauses(clauses [][]int) ([][]int, bool) {
+	return clauses, len(clauses) <= proofClauseLimit
+}
+
+func formatClause(clause []int, names []string) string {
+	parts := make([]string, 0, len(clause))
+	for _, lit := range clause {
+		if lit < 0 {
+			parts = append(parts, "!"+names[-lit-1])
+		} else {
+			parts = append(parts, names[lit-1])
+		}
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func formatProof(expression ast.Expression) string {
+	negated := &ast.PrefixExpression{Op: "!", Right: expression}
+	if satisfiable, countermodel := Satisfiable(negated); satisfiable {
+		return fmt.Sprintf("%s countermodel: %s", bold("not valid,"), formatAssignment(getAllIdentifiers(expression, nil), countermodel))
+	}
+
+	clauses, names := proofClauses(negated)
+	proof, err := resolution.Refute(clauses, resolutionLimit)
+	if err != nil {
+		return fmt.Sprintf("%s but no refutation was found: %s", bold("valid"), err)
+	}
+
+	used := proof.Used()
+	number := make(map[int]int)
+	rendered := make([]string, 0, len(used))
+	for i, index := range used {
+		number[index] = i + 1
+		rendered = append(rendered, formatClause(proof.Steps[index].Clause, names))
+	}
+	width := 0
+	for _, clause := range rendered {
+		width = max(width, len(clause))
+	}
+
+	lines := []string{bold(fmt.Sprintf("resolution refutation of %s:", negated.Literal()))}
+	for i, index := range used {
+		step := proof.Steps[index]
+		justification := "premise"
+		if !step.IsInput() {
+			justification = fmt.Sprintf("resolve %d, %d on %s", number[step.Parents[0]], number[step.Parents[1]], names[step.Pivot-1])
+		}
+		lines = append(lines, fmt.Sprintf("%3d. %-*s  %s", i+1, width, rendered[i], justification))
+	}
+
+	certificate := proof.LRAT()
+	if err := resolution.CheckLRAT(clauses, certificate); err != nil {
+		lines = append(lines, fmt.Sprintf("LRAT certificate rejected: %s", err))
+	} else {
+		lines = append(lines, bold("LRAT certificate, verified:"))
+	}
+	lines = append(lines, strings.TrimSuffix(certificate, "\n"))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SimplificationRule is a rule applied by Simplify. Rules are tried in
+// order of decreasing Priority, rules of equal priority in the order they
+// were registered, and disabled rules are skipped.
+type SimplificationRule struct {
+	Name        string
+	Description string
+	Priority    int
+	Enabled     bool
+	Apply       func(ast.Expression) (bool, ast.Expression)
+	// Guarded rules may grow the formula, their rewrites are simplified
+	// further and kept only when that lowers the cost, so that Simplify
+	// cannot cycle between factored and expanded forms.
+	Guarded bool
+	// Equations state what Apply does as patterns, they are the rules of
+	// Saturate.
+	Equations []Equation
+	// Aliases are further names a derivation step may give the law by.
+	Aliases []string
+}
+
+// Equation states that formulas matching Pattern equal Replacement, with
+// metavariables written like for rewrite.Rule.
+type Equation struct {
+	Pattern     ast.Expression
+	Replacement ast.Expression
+}
+
+// equations parses laws written as "pattern = replacement".
+func equations(sources ...string) []Equation {
+	result := make([]Equation, 0, len(sources))
+	for _, source := range sources {
+		pattern, replacement, _ := strings.Cut(source, " = ")
+		result = append(result, Equation{Pattern: parseRuleSide(pattern), Replacement: parseRuleSide(replacement)})
+	}
+	return result
+}
+
+// builtinRules are the rules of a new Evaluator.
+var builtinRules = []SimplificationRule{
+	{
+		Name: "identity", Description: "a + 0 = a, a + 1 = 1, a * 1 = a, a * 0 = 0", Priority: 150, Apply: IdentityRule,
+		Equations: equations("A + 0 = A", "A + 1 = 1", "A * 1 = A", "A * 0 = 0"),
+	},
+	{
+		Name: "negated constant", Description: "!0 = 1, !1 = 0", Priority: 145, Apply: NegatedConstantRule,
+		Equations: equations("!0 = 1", "!1 = 0"),
+	},
+	{
+		Name: "idempotence", Description: "a + a = a, a * a = a", Priority: 140, Apply: IdempotenceRule,
+		Equations: equations("A + A = A", "A * A = A"),
+		Aliases:   []string{"idempotent"},
+	},
+	{
+		Name: "negated alternative", Description: "a + !a = 1", Priority: 130, Apply: NegatedAlternativeRule,
+		Equations: equations("A + !A = 1"),
+		Aliases:   []string{"complement", "excluded middle"},
+	},
+	{
+		Name: "negated conjunction", Description: "a * !a = 0", Priority: 120, Apply: NegatedConjunctionRule,
+		Equations: equations("A * !A = 0"),
+		Aliases:   []string{"complement", "contradiction"},
+	},
+	{
+		Name: "implication", Description: "a -> b = !a + b", Priority: 110, Apply: ImplicationRule,
+		Equations: equations("A -> B = !A + B"),
+	},
+	{
+		Name: "De Morgan", Description: "!(a * b) = !a + !b, !(a + b) = !a * !b", Priority: 100, Apply: DeMorganRule,
+		Equations: equations("!(A * B) = !A + !B", "!(A + B) = !A * !B"),
+	},
+	{
+		Name: "double negation", Description: "!!a = a", Priority: 90, Apply: DoubleNegationRule,
+		Equations: equations("!!A = A"),
+		Aliases:   []string{"involution"},
+	},
+	{
+		Name: "duplicate alternative", Description: "a + b + a = a + b", Priority: 80, Apply: DuplicateAlternativeRule,
+		Equations: equations("A + A = A"),
+		Aliases:   []string{"idempotence", "idempotent"},
+	},
+	{
+		Name: "biconditional", Description: "a <-> b = (a -> b) * (b -> a)", Priority: 70, Apply: BiconditionalRule,
+		Equations: equations("A <-> A = 1", "A <-> B = (A -> B) * (B -> A)"),
+	},
+	{
+		Name: "exclusive or", Description: "a ^ b = a * !b + !a * b", Priority: 65, Apply: ExclusiveOrRule,
+		Equations: equations("A ^ B = (A * !B) + (!A * B)"),
+		Aliases:   []string{"xor"},
+	},
+	{
+		Name: "absorption", Description: "a + a * b = a, a * (a + b) = a", Priority: 60, Apply: AbsorptionRule,
+		Equations: equations("A + (A * B) = A", "A * (A + B) = A"),
+	},
+	{
+		Name: "complement absorption", Description: "a + !a * b = a + b, a * (!a + b) = a * b", Priority: 50, Apply: ComplementAbsorptionRule,
+		Equations: equations("A + (!A * B) = A + B", "A * (!A + B) = A * B"),
+	},
+	{
+		Name: "complement factoring", Description: "a * b + a * !b = a, (a + b) * (a + !b) = a", Priority: 40, Apply: ComplementFactoringRule,
+		Equations: equations("(A * B) + (A * !B) = A", "(A + B) * (A + !B) = A"),
+	},
+	{
+		Name: "consensus", Description: "a * b + !a * c + b * c = a * b + !a * c", Priority: 30, Apply: ConsensusRule,
+		Equations: equations(
+			"((A * B) + (!A * C)) + (B * C) = (A * B) + (!A * C)",
+			"((A + B) * (!A + C)) * (B + C) = (A + B) * (!A + C)",
+		),
+	},
+	{
+		Name: "distribution", Description: "a * b + a * c = a * (b + c), (a + b) * (a + c) = a + b * c", Priority: 20, Apply: DistributionRule,
+		Equations: equations("(A * B) + (A * C) = A * (B + C)", "(A + B) * (A + C) = A + (B * C)"),
+		Aliases:   []string{"distributivity"},
+	},
+	{
+		Name: "expansion", Description: "a * (b + c) = a * b + a * c when that simplifies further", Priority: 10, Apply: ExpansionRule, Guarded: true,
+		Equations: equations("A * (B + C) = (A * B) + (A * C)", "A + (B * C) = (A + B) * (A + C)"),
+		Aliases:   []string{"distribution", "distributivity"},
+	},
+}
+
+// RulePresets name sets of rules, applying a preset enables exactly its
+// rules.
+var RulePresets = map[string][]string{
+	"textbook": {
+		"identity", "negated constant", "idempotence", "negated alternative", "negated conjunction", "implication",
+		"De Morgan", "double negation", "duplicate alternative", "biconditional", "exclusive or", "absorption",
+		"complement absorption", "complement factoring", "consensus", "distribution", "expansion",
+	},
+	"minimal": {
+		"identity", "negated constant", "idempotence", "negated alternative", "negated conjunction", "double negation",
+	},
+	"nnf-only": {
+		"implication", "biconditional", "exclusive or", "De Morgan", "double negation",
+	},
+}
+
+// Rules returns the rules of the evaluator in the order Simplify tries them.
+func (e *Evaluator) Rules() []SimplificationRule {
+	return slices.Clone(e.simplificationRules)
+}
+
+// RegisterRule adds a rule, replacing a rule with the same name.
+func (e *Evaluator) RegisterRule(rule SimplificationRule) {
+	e.simplificationRules = slices.DeleteFunc(e.simplificationRules, func(r SimplificationRule) bool {
+		return r.Name == rule.Name
+	})
+	e.simplificationRules = append(e.simplificationRules, rule)
+	e.sortRules()
+}
+
+// RegisterSimplificationRule adds an enabled rule of priority 0, after the
+// built-in rules. A rule with the same name is replaced.
+func (e *Evaluator) RegisterSimplificationRule(name string, fn func(ast.Expression) (bool, ast.Expression)) {
+	e.RegisterRule(SimplificationRule{Name: name, Apply: fn, Enabled: true})
+}
+
+func (e *Evaluator) sortRules() {
+	slices.SortStableFunc(e.simplificationRules, func(a, b SimplificationRule) int {
+		return b.Priority - a.Priority
+	})
+}
+
+// findRule returns the position of the rule with the name, ignoring case.
+func (e *Evaluator) findRule(name string) (int, error) {
+	for i, rule := range e.simplificationRules {
+		if strings.EqualFold(rule.Name, name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown rule %s", name)
+}
+
+// ruleEnabled reports whether the rule with the name exists and is enabled.
+func (e *Evaluator) ruleEnabled(name string) bool {
+	i, err := e.findRule(name)
+	return err == nil && e.simplificationRules[i].Enabled
+}
+
+// EnableRule enables or disables the rule with the name.
+func (e *Evaluator) EnableRule(name string, enabled bool) error {
+	i, err := e.findRule(name)
+	if err != nil {
+		return err
+	}
+	e.simplificationRules[i].Enabled = enabled
+	return nil
+}
+
+// SetRulePriority changes when the rule with the name is tried.
+func (e *Evaluator) SetRulePriority(name string, priority int) error {
+	i, err := e.findRule(name)
+	if err != nil {
+		return err
+	}
+	e.simplificationRules[i].Priority = priority
+	e.sortRules()
+	return nil
+}
+
+// ApplyPreset enables the rules of the preset and disables all others,
+// rules defined by the user included.
+func (e *Evaluator) ApplyPreset(name string) error {
+	names, ok := RulePresets[name]
+	if !ok {
+		return fmt.Errorf("unknown preset %s, the presets are %s", name, strings.Join(presetNames(), ", "))
+	}
+	for i, rule := range e.simplificationRules {
+		e.simplificationRules[i].Enabled = contains(names, rule.Name)
+	}
+	return nil
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(RulePresets))
+	for name := range RulePresets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (e *Evaluator) formatRules() string {
+	width := 0
+	for _, rule := range e.simplificationRules {
+		width = max(width, len(rule.Name))
+	}
+
+	lines := make([]string, 0, len(e.simplificationRules))
+	for _, rule := range e.simplificationRules {
+		mark := "\033[32m✓\033[0m"
+		if !rule.Enabled {
+			mark = "\033[31m✗\033[0m"
+		}
+		lines = append(lines, fmt.Sprintf("%s %4d  %-*s  %s", mark, rule.Priority, width, rule.Name, rule.Description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rulesCommand runs the .rules command of the REPL:
+//
+//	.rules                          lists the rules
+//	.rules enable <name>            enables a rule
+//	.rules disable <name>           disables a rule
+//	.rules priority <name> <n>      sets the priority of a rule
+//	.rules preset <preset>          enables exactly the rules of a preset
+func (e *Evaluator) rulesCommand(arguments string) string {
+	command, rest, _ := strings.Cut(strings.TrimSpace(arguments), " ")
+	rest = strings.TrimSpace(rest)
+
+	var err error
+	switch command {
+	case "":
+		return e.formatRules()
+	case "enable", "disable":
+		err = e.EnableRule(rest, command == "enable")
+	case "priority":
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			return "usage: .rules priority <name> <priority>"
+		}
+		priority, convErr := strconv.Atoi(fields[len(fields)-1])
+		if convErr != nil {
+			return fmt.Sprintf("invalid priority %s", fields[len(fields)-1])
+		}
+		err = e.SetRulePriority(strings.Join(fields[:len(fields)-1], " "), priority)
+	case "preset":
+		err = e.ApplyPreset(rest)
+	default:
+		return "usage: .rules [enable <name> | disable <name> | priority <name> <priority> | preset <" + strings.Join(presetNames(), "|") + ">]"
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return e.formatRules()
+}
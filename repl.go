@@ -14,11 +14,17 @@ func RunRepl() {
 	fmt.Println("Welcome to Logix REPL!")
 	fmt.Println("Type \".exit\" or press Ctrl+D to quit.")
 	fmt.Print(">> ")
+	evaluator := NewEvaluator()
 	for scanner.Scan() {
 		text := scanner.Text()
 		if strings.HasPrefix(text, ".exit") {
 			break
 		}
+		if command, ok := strings.CutPrefix(text, ".rules"); ok {
+			fmt.Println(evaluator.rulesCommand(command))
+			fmt.Print(">> ")
+			continue
+		}
 		tok := tokenizer.NewTokenizer(text)
 		if err := tok.Tokenize(); err != nil {
 			fmt.Println(err)
@@ -32,7 +38,6 @@ func RunRepl() {
 			fmt.Print(">> ")
 			continue
 		}
-		evaluator := NewEvaluator()
 		fmt.Println(evaluator.evaluate(statement))
 		fmt.Print(">> ")
 	}
@@ -0,0 +1,244 @@
+package resolution
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The checkers below only read the input clauses and the certificate text,
+// they share nothing with the search so that a bug in the prover cannot hide
+// an invalid proof.
+
+// CheckLRAT verifies an LRAT certificate against the input clauses, which
+// are numbered from 1. Every added clause must follow from its hint clauses
+// by unit propagation, and the certificate must add the empty clause.
+func CheckLRAT(clauses [][]int, certificate string) error {
+	database := make(map[int][]int)
+	for i, clause := range clauses {
+		database[i+1] = clause
+	}
+
+	lastID := len(clauses)
+	scanner := bufio.NewScanner(strings.NewReader(certificate))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+
+		numbers, deletion, err := parseNumbers(fields)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		id := numbers[0]
+		if deletion {
+			for _, deleted := range numbers[1:] {
+				if deleted == 0 {
+					break
+				}
+				delete(database, deleted)
+			}
+			continue
+		}
+
+		if id <= lastID {
+			return fmt.Errorf("line %d: clause id %d is not increasing", line, id)
+		}
+		lastID = id
+
+		clause, hints, err := splitLRAT(numbers[1:])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := checkHints(database, clause, hints); err != nil {
+			return fmt.Errorf("line %d: clause %d: %w", line, id, err)
+		}
+		if len(clause) == 0 {
+			return nil
+		}
+		database[id] = clause
+	}
+
+	return fmt.Errorf("the certificate does not derive the empty clause")
+}
+
+// checkHints assigns the negation of the clause and requires every hint in
+// turn to become unit under the assignment, the last one falsified.
+func checkHints(database map[int][]int, clause []int, hints []int) error {
+	assignment := make(map[int]bool)
+	for _, lit := range clause {
+		assignment[-lit] = true
+	}
+
+	for i, hint := range hints {
+		hinted, ok := database[hint]
+		if !ok {
+			return fmt.Errorf("hint %d refers to an unknown clause", hint)
+		}
+		unassigned := 0
+		unit := 0
+		satisfied := false
+		for _, lit := range hinted {
+			switch {
+			case assignment[lit]:
+				satisfied = true
+			case !assignment[-lit] && lit != unit:
+				// a repeated literal is counted once
+				unassigned++
+				unit = lit
+			}
+		}
+		switch {
+		case satisfied:
+			return fmt.Errorf("hint %d is already satisfied", hint)
+		case unassigned == 0:
+			if i != len(hints)-1 {
+				return fmt.Errorf("hint %d conflicts before the last hint", hint)
+			}
+			return nil
+		case unassigned > 1:
+			return fmt.Errorf("hint %d is not unit", hint)
+		}
+		assignment[unit] = true
+	}
+
+	return fmt.Errorf("the hints do not lead to a conflict")
+}
+
+// CheckDRAT verifies a DRAT certificate that only needs reverse unit
+// propagation, every added clause must follow from the clauses so far by
+// unit propagation alone.
+func CheckDRAT(clauses [][]int, certificate string) error {
+	database := make([][]int, 0, len(clauses))
+	database = append(database, clauses...)
+
+	scanner := bufio.NewScanner(strings.NewReader(certificate))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "c" {
+			continue
+		}
+
+		numbers, deletion, err := parseNumbers(fields)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(numbers) == 0 || numbers[len(numbers)-1] != 0 {
+			return fmt.Errorf("line %d: clause is not terminated by 0", line)
+		}
+		clause := numbers[:len(numbers)-1]
+
+		if deletion {
+			for i, existing := range database {
+				if sameClause(existing, clause) {
+					database = append(database[:i], database[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+
+		if !implied(database, clause) {
+			return fmt.Errorf("line %d: clause %v is not implied by unit propagation", line, clause)
+		}
+		if len(clause) == 0 {
+			return nil
+		}
+		database = append(database, clause)
+	}
+
+	return fmt.Errorf("the certificate does not derive the empty clause")
+}
+
+// implied reports whether unit propagation on the database under the
+// negation of the clause reaches a conflict.
+func implied(database [][]int, clause []int) bool {
+	assignment := make(map[int]bool)
+	for _, lit := range clause {
+		if assignment[lit] {
+			return true
+		}
+		assignment[-lit] = true
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, c := range database {
+			unassigned, unit, satisfied := 0, 0, false
+			for _, lit := range c {
+				if assignment[lit] {
+					satisfied = true
+					break
+				}
+				if !assignment[-lit] && lit != unit {
+					unassigned++
+					unit = lit
+				}
+			}
+			if satisfied {
+				continue
+			}
+			if unassigned == 0 {
+				return true
+			}
+			if unassigned == 1 {
+				assignment[unit] = true
+				changed = true
+			}
+		}
+	}
+	return false
+}
+
+func parseNumbers(fields []string) ([]int, bool, error) {
+	deletion := false
+	numbers := make([]int, 0, len(fields))
+	for i, field := range fields {
+		if field == "d" && i <= 1 {
+			deletion = true
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid number %s", field)
+		}
+		numbers = append(numbers, n)
+	}
+	if len(numbers) == 0 {
+		return nil, false, fmt.Errorf("empty line")
+	}
+	return numbers, deletion, nil
+}
+
+func splitLRAT(numbers []int) ([]int, []int, error) {
+	for i, n := range numbers {
+		if n != 0 {
+			continue
+		}
+		hints := numbers[i+1:]
+		if len(hints) == 0 || hints[len(hints)-1] != 0 {
+			return nil, nil, fmt.Errorf("hints are not terminated by 0")
+		}
+		return numbers[:i], hints[:len(hints)-1], nil
+	}
+	return nil, nil, fmt.Errorf("clause is not terminated by 0")
+}
+
+func sameClause(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[int]int)
+	for _, lit := range a {
+		count[lit]++
+	}
+	for _, lit := range b {
+		count[lit]--
+		if count[lit] < 0 {
+			return false
+		}
+	}
+	return true
+}
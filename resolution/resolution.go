@@ -0,0 +1,240 @@
+package resolution
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Clauses use the DIMACS convention, variable v is written v and its
+// negation -v.
+
+var (
+	// ErrSaturated is returned when no new clause can be derived without
+	// reaching the empty clause, the input is then satisfiable.
+	ErrSaturated = errors.New("resolution: clause set is saturated without a refutation")
+	// ErrLimit is returned when the search derives more clauses than allowed.
+	ErrLimit = errors.New("resolution: clause limit reached")
+)
+
+// Step is one clause of a proof. Inputs have no parents, resolvents name the
+// two steps they were resolved from and the variable resolved upon.
+type Step struct {
+	Clause  []int
+	Parents [2]int
+	Pivot   int
+}
+
+func (s Step) IsInput() bool {
+	return s.Parents[0] < 0
+}
+
+// Proof holds every clause considered by the search, the first Inputs steps
+// are the input clauses in their original order and the last step is the
+// empty clause.
+type Proof struct {
+	Inputs int
+	Steps  []Step
+}
+
+// Refute searches for a resolution refutation with the given clause
+// algorithm, always picking the shortest unprocessed clause. At most limit
+// clauses are derived, zero means no limit.
+func Refute(clauses [][]int, limit int) (*Proof, error) {
+	proof := &Proof{Inputs: len(clauses)}
+	known := make(map[string]bool)
+	unprocessed := make([]int, 0)
+
+	add := func(step Step) bool {
+		key := clauseKey(step.Clause)
+		if known[key] {
+			return false
+		}
+		known[key] = true
+		proof.Steps = append(proof.Steps, step)
+		unprocessed = append(unprocessed, len(proof.Steps)-1)
+		return true
+	}
+
+	for _, clause := range clauses {
+		normalized, tautology := normalize(clause)
+		step := Step{Clause: normalized, Parents: [2]int{-1, -1}}
+		if tautology {
+			proof.Steps = append(proof.Steps, step)
+			continue
+		}
+		if !add(step) {
+			proof.Steps = append(proof.Steps, step)
+		}
+		if len(normalized) == 0 {
+			return proof.end(len(proof.Steps) - 1), nil
+		}
+	}
+
+	processed := make([]int, 0)
+	derived := 0
+	for len(unprocessed) > 0 {
+		shortest := 0
+		for i, index := range unprocessed {
+			if len(proof.Steps[index].Clause) < len(proof.Steps[unprocessed[shortest]].Clause) {
+				shortest = i
+			}
+		}
+		given := unprocessed[shortest]
+		unprocessed = slices.Delete(unprocessed, shortest, shortest+1)
+
+		for _, other := range processed {
+			for _, lit := range proof.Steps[given].Clause {
+				if !slices.Contains(proof.Steps[other].Clause, -lit) {
+					continue
+				}
+				resolvent, tautology := resolve(proof.Steps[given].Clause, proof.Steps[other].Clause, lit)
+				if tautology {
+					continue
+				}
+				pivot := lit
+				if pivot < 0 {
+					pivot = -pivot
+				}
+				if !add(Step{Clause: resolvent, Parents: [2]int{given, other}, Pivot: pivot}) {
+					continue
+				}
+				if len(resolvent) == 0 {
+					return proof.end(len(proof.Steps) - 1), nil
+				}
+				derived++
+				if limit > 0 && derived >= limit {
+					return nil, ErrLimit
+				}
+			}
+		}
+		processed = append(processed, given)
+	}
+
+	return nil, ErrSaturated
+}
+
+// end drops every step after the empty clause.
+func (p *Proof) end(empty int) *Proof {
+	p.Steps = p.Steps[:empty+1]
+	return p
+}
+
+// Used returns the indices of the steps the empty clause depends on, in
+// proof order.
+func (p *Proof) Used() []int {
+	used := make([]bool, len(p.Steps))
+	var mark func(int)
+	mark = func(index int) {
+		if used[index] {
+			return
+		}
+		used[index] = true
+		if !p.Steps[index].IsInput() {
+			mark(p.Steps[index].Parents[0])
+			mark(p.Steps[index].Parents[1])
+		}
+	}
+	mark(len(p.Steps) - 1)
+
+	result := make([]int, 0)
+	for index, u := range used {
+		if u {
+			result = append(result, index)
+		}
+	}
+	return result
+}
+
+// LRAT returns the derivation of the empty clause as an LRAT certificate for
+// the input clauses numbered from 1, each line cites its two parents as hints.
+func (p *Proof) LRAT() string {
+	var builder strings.Builder
+	id := make(map[int]int)
+	next := p.Inputs + 1
+	for _, index := range p.Used() {
+		step := p.Steps[index]
+		if step.IsInput() {
+			id[index] = index + 1
+			continue
+		}
+		id[index] = next
+		fmt.Fprintf(&builder, "%d %s0 %d %d 0\n", next, literals(step.Clause), id[step.Parents[0]], id[step.Parents[1]])
+		next++
+	}
+	if last := len(p.Steps) - 1; p.Steps[last].IsInput() {
+		fmt.Fprintf(&builder, "%d 0 %d 0\n", next, id[last])
+	}
+	return builder.String()
+}
+
+// DRAT returns the derivation of the empty clause as a DRAT certificate, the
+// clauses to add in order without hints.
+func (p *Proof) DRAT() string {
+	var builder strings.Builder
+	for _, index := range p.Used() {
+		if step := p.Steps[index]; !step.IsInput() {
+			fmt.Fprintf(&builder, "%s0\n", literals(step.Clause))
+		}
+	}
+	if p.Steps[len(p.Steps)-1].IsInput() {
+		builder.WriteString("0\n")
+	}
+	return builder.String()
+}
+
+func literals(clause []int) string {
+	var builder strings.Builder
+	for _, lit := range clause {
+		builder.WriteString(strconv.Itoa(lit))
+		builder.WriteByte(' ')
+	}
+	return builder.String()
+}
+
+func resolve(a []int, b []int, lit int) ([]int, bool) {
+	result := make([]int, 0, len(a)+len(b)-2)
+	for _, l := range a {
+		if l != lit {
+			result = append(result, l)
+		}
+	}
+	for _, l := range b {
+		if l != -lit {
+			result = append(result, l)
+		}
+	}
+	return normalize(result)
+}
+
+// normalize sorts a clause and removes repeated literals, the second result
+// reports a clause containing a literal and its negation.
+func normalize(clause []int) ([]int, bool) {
+	result := slices.Clone(clause)
+	slices.SortFunc(result, func(a, b int) int {
+		if abs(a) != abs(b) {
+			return abs(a) - abs(b)
+		}
+		return a - b
+	})
+	result = slices.Compact(result)
+	for i := 1; i < len(result); i++ {
+		if result[i] == -result[i-1] {
+			return result, true
+		}
+	}
+	return result, false
+}
+
+func clauseKey(clause []int) string {
+	return literals(clause)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,104 @@
+package resolution
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func satisfiable(clauses [][]int, vars int) bool {
+	for bits := 0; bits < 1<<vars; bits++ {
+		satisfied := true
+		for _, clause := range clauses {
+			any := false
+			for _, lit := range clause {
+				any = any || (bits&(1<<(abs(lit)-1)) != 0) == (lit > 0)
+			}
+			satisfied = satisfied && any
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRefutation refutes the clauses and runs both certificates through the
+// checkers, it reports whether a refutation was found.
+func checkRefutation(t *testing.T, clauses [][]int) bool {
+	t.Helper()
+	proof, err := Refute(clauses, 0)
+	if errors.Is(err, ErrSaturated) {
+		return false
+	}
+	if err != nil {
+		t.Fatalf("%v: %v", clauses, err)
+	}
+	if err := CheckLRAT(clauses, proof.LRAT()); err != nil {
+		t.Fatalf("%v: LRAT rejected: %v\n%s", clauses, err, proof.LRAT())
+	}
+	if err := CheckDRAT(clauses, proof.DRAT()); err != nil {
+		t.Fatalf("%v: DRAT rejected: %v\n%s", clauses, err, proof.DRAT())
+	}
+	return true
+}
+
+func TestRefuteCertificates(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		vars := 1 + r.Intn(4)
+		clauses := make([][]int, r.Intn(4*vars+2))
+		for j := range clauses {
+			for k := r.Intn(4); k >= 0; k-- {
+				lit := 1 + r.Intn(vars)
+				if r.Intn(2) == 0 {
+					lit = -lit
+				}
+				clauses[j] = append(clauses[j], lit)
+			}
+		}
+		if refuted := checkRefutation(t, clauses); refuted == satisfiable(clauses, vars) {
+			t.Fatalf("%v: refuted %t, but satisfiable is %t", clauses, refuted, satisfiable(clauses, vars))
+		}
+	}
+}
+
+func TestRefuteSpecialInputs(t *testing.T) {
+	cases := map[string][][]int{
+		"an empty input clause":           {{1, 2}, {}, {-1}},
+		"only the empty clause":           {{}},
+		"duplicate clauses":               {{1, 2}, {1, 2}, {-1}, {-1}, {-2, 1}},
+		"duplicate literals":              {{1, 1, 2}, {-2, -2}, {-1}},
+		"tautologies":                     {{1, -1}, {2, -2, 3}, {1}, {-1, 2}, {-2}},
+		"a duplicate of the empty clause": {{1}, {}, {}},
+	}
+	for name, clauses := range cases {
+		if !checkRefutation(t, clauses) {
+			t.Errorf("%s: %v was not refuted", name, clauses)
+		}
+	}
+
+	if checkRefutation(t, [][]int{{1, -1}, {2, 2}}) {
+		t.Error("the satisfiable {1 -1} {2 2} was refuted")
+	}
+}
+
+func TestCheckersRejectBrokenCertificates(t *testing.T) {
+	clauses := [][]int{{-1, 2}, {-2, 3}, {1}, {-3}}
+	broken := map[string]string{
+		"wrong hint":      "5 2 0 1 4 0\n6 -2 0 2 4 0\n7 0 6 5 0\n",
+		"no empty clause": "5 2 0 1 3 0\n",
+		"unknown hint":    "5 2 0 1 9 0\n6 0 5 4 0\n",
+	}
+	for name, certificate := range broken {
+		if err := CheckLRAT(clauses, certificate); err == nil {
+			t.Errorf("LRAT with a %s was accepted", name)
+		}
+	}
+	if err := CheckDRAT(clauses[:3], "3 0\n0\n"); err == nil {
+		t.Error("DRAT adding a clause that does not follow was accepted")
+	}
+	if err := CheckDRAT(clauses, "2 0\n"); err == nil {
+		t.Error("DRAT without the empty clause was accepted")
+	}
+}
@@ -0,0 +1,237 @@
+package rewrite
+
+import (
+	"errors"
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"unicode"
+)
+
+// Rule rewrites subterms matching Pattern into Replacement. Identifiers
+// starting with an upper case letter are metavariables, a metavariable
+// matches any subterm and all its occurrences must match equal subterms.
+// Other identifiers and constants only match themselves. Conjunctions and
+// disjunctions are flattened, see ast.Flatten, and match modulo
+// associativity and commutativity.
+type Rule struct {
+	Name        string
+	Pattern     ast.Expression
+	Replacement ast.Expression
+}
+
+// IsMetavariable reports whether the identifier is a metavariable.
+func IsMetavariable(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+// Metavariables returns the metavariables of an expression in order of
+// first occurrence.
+func Metavariables(expression ast.Expression) []string {
+	result := make([]string, 0)
+	var visit func(ast.Expression)
+	visit = func(expression ast.Expression) {
+		switch expr := expression.(type) {
+		case *ast.Identifier:
+			if IsMetavariable(expr.Value) {
+				for _, name := range result {
+					if name == expr.Value {
+						return
+					}
+				}
+				result = append(result, expr.Value)
+			}
+		case *ast.PrefixExpression:
+			visit(expr.Right)
+		case *ast.InfixExpression:
+			visit(expr.Left)
+			visit(expr.Right)
+		case *ast.NaryExpression:
+			for _, operand := range expr.Operands {
+				visit(operand)
+			}
+		}
+	}
+	visit(expression)
+	return result
+}
+
+// Compile checks that a rule is well formed: the pattern must not be a bare
+// metavariable, which would match every subterm, and the replacement may
+// only use metavariables bound by the pattern.
+func Compile(name string, pattern ast.Expression, replacement ast.Expression) (*Rule, error) {
+	if ident, ok := pattern.(*ast.Identifier); ok && IsMetavariable(ident.Value) {
+		return nil, errors.New("rewrite: the pattern must not be a bare metavariable")
+	}
+
+	bound := Metavariables(pattern)
+	for _, name := range Metavariables(replacement) {
+		found := false
+		for _, b := range bound {
+			found = found || b == name
+		}
+		if !found {
+			return nil, fmt.Errorf("rewrite: metavariable %s of the replacement does not occur in the pattern", name)
+		}
+	}
+
+	return &Rule{Name: name, Pattern: ast.Flatten(pattern), Replacement: ast.Flatten(replacement)}, nil
+}
+
+func (r *Rule) String() string {
+	return fmt.Sprintf("%s: %s => %s", r.Name, r.Pattern.Literal(), r.Replacement.Literal())
+}
+
+// Match binds the metavariables of the pattern so that it equals the
+// expression. Operands of commutative operators match in either order, and
+// the operands of a flattened conjunction or disjunction in any order.
+func (r *Rule) Match(expression ast.Expression) (map[string]ast.Expression, bool) {
+	return match(r.Pattern, ast.Flatten(expression), make(map[string]ast.Expression))
+}
+
+// Apply rewrites the expression when the pattern matches it, it has the
+// signature of the evaluator's simplification rules and like them expects a
+// flattened expression, see ast.Flatten. A conjunction or disjunction
+// pattern also matches some of the operands of a longer chain, a + !a
+// matches b + a + !a, the other operands are kept next to the replacement.
+func (r *Rule) Apply(expression ast.Expression) (bool, ast.Expression) {
+	if pattern, ok := r.Pattern.(*ast.NaryExpression); ok {
+		subject, ok := expression.(*ast.NaryExpression)
+		if !ok || subject.Action != pattern.Action || len(subject.Operands) < len(pattern.Operands) {
+			return false, expression
+		}
+		bindings, used, ok := matchOperands(pattern.Operands, subject.Operands, make([]bool, len(subject.Operands)), make(map[string]ast.Expression))
+		if !ok {
+			return false, expression
+		}
+		operands := []ast.Expression{substitute(r.Replacement, bindings)}
+		for i, operand := range subject.Operands {
+			if !used[i] {
+				operands = append(operands, operand)
+			}
+		}
+		return true, ast.NewNary(subject.Action, operands)
+	}
+
+	bindings, ok := match(r.Pattern, expression, make(map[string]ast.Expression))
+	if !ok {
+		return false, expression
+	}
+	return true, substitute(r.Replacement, bindings)
+}
+
+func match(pattern ast.Expression, expression ast.Expression, bindings map[string]ast.Expression) (map[string]ast.Expression, bool) {
+	switch p := pattern.(type) {
+	case *ast.Identifier:
+		if !IsMetavariable(p.Value) {
+			ident, ok := expression.(*ast.Identifier)
+			return bindings, ok && ident.Value == p.Value
+		}
+		if bound, ok := bindings[p.Value]; ok {
+			return bindings, bound.Literal() == expression.Literal()
+		}
+		extended := make(map[string]ast.Expression, len(bindings)+1)
+		for name, value := range bindings {
+			extended[name] = value
+		}
+		extended[p.Value] = expression
+		return extended, true
+
+	case *ast.Boolean:
+		boolean, ok := expression.(*ast.Boolean)
+		return bindings, ok && boolean.Value == p.Value
+
+	case *ast.PrefixExpression:
+		prefix, ok := expression.(*ast.PrefixExpression)
+		if !ok || prefix.Op != p.Op {
+			return bindings, false
+		}
+		return match(p.Right, prefix.Right, bindings)
+
+	case *ast.InfixExpression:
+		infix, ok := expression.(*ast.InfixExpression)
+		if !ok || infix.Action != p.Action {
+			return bindings, false
+		}
+		if result, ok := matchPair(p.Left, p.Right, infix.Left, infix.Right, bindings); ok {
+			return result, true
+		}
+		if commutative(p.Action) {
+			return matchPair(p.Left, p.Right, infix.Right, infix.Left, bindings)
+		}
+
+	case *ast.NaryExpression:
+		nary, ok := expression.(*ast.NaryExpression)
+		if !ok || nary.Action != p.Action || len(nary.Operands) != len(p.Operands) {
+			return bindings, false
+		}
+		result, _, ok := matchOperands(p.Operands, nary.Operands, make([]bool, len(nary.Operands)), bindings)
+		return result, ok
+	}
+	return bindings, false
+}
+
+func matchPair(patternLeft, patternRight, left, right ast.Expression, bindings map[string]ast.Expression) (map[string]ast.Expression, bool) {
+	bindings, ok := match(patternLeft, left, bindings)
+	if !ok {
+		return bindings, false
+	}
+	return match(patternRight, right, bindings)
+}
+
+// matchOperands matches every pattern to a distinct operand not yet used,
+// backtracking over the choices, and returns the operands it used.
+func matchOperands(patterns []ast.Expression, operands []ast.Expression, used []bool, bindings map[string]ast.Expression) (map[string]ast.Expression, []bool, bool) {
+	if len(patterns) == 0 {
+		return bindings, used, true
+	}
+	for i, operand := range operands {
+		if used[i] {
+			continue
+		}
+		extended, ok := match(patterns[0], operand, bindings)
+		if !ok {
+			continue
+		}
+		used[i] = true
+		if result, used, ok := matchOperands(patterns[1:], operands, used, extended); ok {
+			return result, used, true
+		}
+		used[i] = false
+	}
+	return bindings, used, false
+}
+
+func commutative(action string) bool {
+	switch action {
+	case "and", "or", "xor", "<->":
+		return true
+	}
+	return false
+}
+
+// substitute instantiates the replacement with copies of the bound subterms.
+func substitute(replacement ast.Expression, bindings map[string]ast.Expression) ast.Expression {
+	switch expr := replacement.(type) {
+	case *ast.Identifier:
+		if bound, ok := bindings[expr.Value]; ok {
+			return ast.Clone(bound)
+		}
+		return &ast.Identifier{Value: expr.Value}
+	case *ast.Boolean:
+		return &ast.Boolean{Value: expr.Value}
+	case *ast.PrefixExpression:
+		return &ast.PrefixExpression{Op: expr.Op, Right: substitute(expr.Right, bindings)}
+	case *ast.InfixExpression:
+		return &ast.InfixExpression{Op: expr.Op, Action: expr.Action, Left: substitute(expr.Left, bindings), Right: substitute(expr.Right, bindings)}
+	case *ast.NaryExpression:
+		operands := make([]ast.Expression, 0, len(expr.Operands))
+		for _, operand := range expr.Operands {
+			operands = append(operands, substitute(operand, bindings))
+		}
+		return ast.NewNary(expr.Action, operands)
+	}
+	return ast.Clone(replacement)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/parser"
+	"github.com/terawatthour/logix/rewrite"
+	"github.com/terawatthour/logix/tokenizer"
+	"os"
+	"strings"
+)
+
+// DefineRule compiles a declarative rewrite rule and registers it on the
+// evaluator. Metavariables stand for arbitrary formulas, so a rule is sound
+// exactly when its two sides are equivalent with the metavariables read as
+// identifiers, rules that are not are rejected.
+func (e *Evaluator) DefineRule(name string, pattern ast.Expression, replacement ast.Expression) (*rewrite.Rule, error) {
+	rule, err := rewrite.Compile(name, pattern, replacement)
+	if err != nil {
+		return nil, err
+	}
+	if equivalent, difference := Equivalent(pattern, replacement); !equivalent {
+		idents := getIdentifiersOfAll([]ast.Expression{pattern, replacement})
+		return nil, fmt.Errorf("rule %s is unsound, %s and %s differ at %s", name, pattern.Literal(), replacement.Literal(), plainAssignment(idents, difference))
+	}
+
+	e.RegisterRule(SimplificationRule{
+		Name:        name,
+		Description: fmt.Sprintf("%s => %s", pattern.Literal(), replacement.Literal()),
+		Enabled:     true,
+		Apply:       rule.Apply,
+		Equations:   []Equation{{Pattern: pattern, Replacement: replacement}},
+	})
+	return rule, nil
+}
+
+func (e *Evaluator) formatRuleDefinition(stmt *ast.RuleStatement) string {
+	rule, err := e.DefineRule(stmt.Name, stmt.Pattern, stmt.Replacement)
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("registered %s", rule)
+}
+
+// formatRuleFile defines the rules of a file holding one rule statement per
+// line, blank lines and lines starting with # are skipped.
+func (e *Evaluator) formatRuleFile(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return err.Error()
+	}
+	defer file.Close()
+
+	lines := make([]string, 0)
+	registered := 0
+	scanner := bufio.NewScanner(file)
+	for row := 1; scanner.Scan(); row++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		tok := tokenizer.NewTokenizer(text)
+		if err := tok.Tokenize(); err != nil {
+			lines = append(lines, fmt.Sprintf("%s:%d: %s", path, row, err))
+			continue
+		}
+		statement, err := parser.NewParser(tok).Parse()
+		if err != nil {
+			lines = append(lines, fmt.Sprintf("%s:%d: %s", path, row, strings.Join(err.(*parser.ParsingError).Errors, ", ")))
+			continue
+		}
+		stmt, ok := statement.(*ast.RuleStatement)
+		if !ok {
+			lines = append(lines, fmt.Sprintf("%s:%d: expected a rule, got %s", path, row, statement.Literal()))
+			continue
+		}
+		if _, err := e.DefineRule(stmt.Name, stmt.Pattern, stmt.Replacement); err != nil {
+			lines = append(lines, fmt.Sprintf("%s:%d: %s", path, row, err))
+			continue
+		}
+		registered++
+	}
+	if err := scanner.Err(); err != nil {
+		lines = append(lines, err.Error())
+	}
+
+	lines = append(lines, fmt.Sprintf("registered %d rule(s) from %s", registered, path))
+	return strings.Join(lines, "\n")
+}
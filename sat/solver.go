@@ -0,0 +1,632 @@
+package sat
+
+import (
+	"sort"
+)
+
+// Literals are exchanged with the solver in the DIMACS convention: variable v
+// is written as v and its negation as -v. Variables are numbered from 1.
+// Internally a literal is stored as 2*(v-1) for v and 2*(v-1)+1 for -v.
+
+const (
+	varDecay    = 0.95
+	clauseDecay = 0.999
+	restartBase = 100
+)
+
+type clause struct {
+	lits     []int
+	learnt   bool
+	activity float64
+	deleted  bool
+}
+
+type Solver struct {
+	clauses []*clause
+	learnts []*clause
+	watches [][]*clause
+
+	assigns  []int8
+	level    []int
+	reason   []*clause
+	polarity []bool
+	seen     []bool
+
+	trail    []int
+	trailLim []int
+	qhead    int
+
+	activity  []float64
+	varInc    float64
+	clauseInc float64
+	order     *varHeap
+
+	maxLearnts float64
+	ok         bool
+	model      []bool
+	conflict   []int
+
+	Conflicts    int
+	Decisions    int
+	Propagations int
+	Restarts     int
+}
+
+// NewSolver creates an empty solver, variables are allocated on demand by
+// NewVar or implicitly by AddClause.
+func NewSolver() *Solver {
+	s := &Solver{
+		varInc:    1,
+		clauseInc: 1,
+		ok:        true,
+	}
+	s.order = &varHeap{activity: &s.activity}
+	return s
+}
+
+// NewVar allocates a fresh variable and returns its DIMACS index.
+func (s *Solver) NewVar() int {
+	v := len(s.assigns)
+	s.watches = append(s.watches, nil, nil)
+	s.assigns = append(s.assigns, 0)
+	s.level = append(s.level, 0)
+	s.reason = append(s.reason, nil)
+	s.polarity = append(s.polarity, true)
+	s.seen = append(s.seen, false)
+	s.activity = append(s.activity, 0)
+	s.order.insert(v)
+	return v + 1
+}
+
+func (s *Solver) NumVars() int {
+	return len(s.assigns)
+}
+
+func (s *Solver) NumClauses() int {
+	return len(s.clauses)
+}
+
+// AddClause adds a disjunction of DIMACS literals. It returns false once the
+// clause set is known to be unsatisfiable without any search.
+func (s *Solver) AddClause(lits ...int) bool {
+	if !s.ok {
+		return false
+	}
+	s.cancelUntil(0)
+
+	normalized := make([]int, 0, len(lits))
+	for _, l := range lits {
+		if l == 0 {
+			panic("sat: literal 0 is not a valid literal")
+		}
+		for abs(l) > s.NumVars() {
+			s.NewVar()
+		}
+		lit := toInternal(l)
+		switch s.litValue(lit) {
+		case 1:
+			return true
+		case -1:
+			continue
+		}
+		if containsInt(normalized, lit^1) {
+			return true
+		}
+		if !containsInt(normalized, lit) {
+			normalized = append(normalized, lit)
+		}
+	}
+
+	switch len(normalized) {
+	case 0:
+		s.ok = false
+	case 1:
+		s.enqueue(normalized[0], nil)
+		if s.propagate() != nil {
+			s.ok = false
+		}
+	default:
+		c := &clause{lits: normalized}
+		s.clauses = append(s.clauses, c)
+		s.attach(c)
+	}
+
+	return s.ok
+}
+
+// Solve decides satisfiability of the clause set under the given assumption
+// literals. After a successful call Value and Model report the assignment
+// found, after an unsuccessful one Conflict reports the assumptions involved.
+func (s *Solver) Solve(assumptions ...int) bool {
+	s.model = nil
+	s.conflict = nil
+	if !s.ok {
+		return false
+	}
+	for _, a := range assumptions {
+		for abs(a) > s.NumVars() {
+			s.NewVar()
+		}
+	}
+
+	if s.maxLearnts == 0 {
+		s.maxLearnts = float64(len(s.clauses))/3 + 100
+	}
+
+	result := int8(0)
+	for restart := 0; result == 0; restart++ {
+		result = s.search(restartBase*luby(2, restart), assumptions)
+		if result == 0 {
+			s.Restarts++
+		}
+	}
+
+	if result == 1 {
+		s.model = make([]bool, s.NumVars())
+		for v := range s.assigns {
+			s.model[v] = s.assigns[v] == 1
+		}
+	}
+	s.cancelUntil(0)
+
+	return result == 1
+}
+
+// Value reports the value of variable v in the last model found.
+func (s *Solver) Value(v int) bool {
+	if s.model == nil || v < 1 || v > len(s.model) {
+		return false
+	}
+	return s.model[v-1]
+}
+
+// Model returns the last model found indexed by variable minus one, or nil
+// when the last call to Solve did not succeed.
+func (s *Solver) Model() []bool {
+	return s.model
+}
+
+// Conflict returns the subset of assumptions that made the last call to
+// Solve fail. It is empty when the clause set is unsatisfiable on its own.
+func (s *Solver) Conflict() []int {
+	return s.conflict
+}
+
+func (s *Solver) search(conflictBudget int, assumptions []int) int8 {
+	conflicts := 0
+	for {
+		confl := s.propagate()
+		if confl != nil {
+			s.Conflicts++
+			conflicts++
+			if s.decisionLevel() == 0 {
+				s.ok = false
+				return -1
+			}
+
+			learnt, backtrackLevel := s.analyze(confl)
+			s.cancelUntil(backtrackLevel)
+			if len(learnt) == 1 {
+				s.enqueue(learnt[0], nil)
+			} else {
+				c := &clause{lits: learnt, learnt: true}
+				s.learnts = append(s.learnts, c)
+				s.attach(c)
+				s.bumpClause(c)
+				s.enqueue(learnt[0], c)
+			}
+
+			s.varInc /= varDecay
+			s.clauseInc /= clauseDecay
+			continue
+		}
+
+		if conflicts >= conflictBudget {
+			s.cancelUntil(0)
+			return 0
+		}
+
+		if s.decisionLevel() == 0 {
+			s.simplify()
+		}
+
+		if float64(len(s.learnts))-float64(len(s.trail)) >= s.maxLearnts {
+			s.reduceLearnts()
+		}
+
+		next := -1
+		for s.decisionLevel() < len(assumptions) {
+			p := toInternal(assumptions[s.decisionLevel()])
+			if value := s.litValue(p); value == 1 {
+				s.newDecisionLevel()
+			} else if value == -1 {
+				s.analyzeFinal(p ^ 1)
+				return -1
+			} else {
+				next = p
+				break
+			}
+		}
+
+		if next == -1 {
+			next = s.pickBranchLit()
+			if next == -1 {
+				return 1
+			}
+			s.Decisions++
+		}
+
+		s.newDecisionLevel()
+		s.enqueue(next, nil)
+	}
+}
+
+func (s *Solver) propagate() *clause {
+	for s.qhead < len(s.trail) {
+		falseLit := s.trail[s.qhead] ^ 1
+		s.qhead++
+		s.Propagations++
+
+		ws := s.watches[falseLit]
+		i, j := 0, 0
+		for i < len(ws) {
+			c := ws[i]
+			i++
+			if c.deleted {
+				continue
+			}
+			if c.lits[0] == falseLit {
+				c.lits[0], c.lits[1] = c.lits[1], c.lits[0]
+			}
+
+			if s.litValue(c.lits[0]) == 1 {
+				ws[j] = c
+				j++
+				continue
+			}
+
+			moved := false
+			for k := 2; k < len(c.lits); k++ {
+				if s.litValue(c.lits[k]) != -1 {
+					c.lits[1], c.lits[k] = c.lits[k], c.lits[1]
+					s.watches[c.lits[1]] = append(s.watches[c.lits[1]], c)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+
+			ws[j] = c
+			j++
+			if s.litValue(c.lits[0]) == -1 {
+				for i < len(ws) {
+					ws[j] = ws[i]
+					i++
+					j++
+				}
+				s.watches[falseLit] = ws[:j]
+				s.qhead = len(s.trail)
+				return c
+			}
+			s.enqueue(c.lits[0], c)
+		}
+		s.watches[falseLit] = ws[:j]
+	}
+	return nil
+}
+
+// analyze derives the first unique implication point clause from a conflict,
+// the asserting literal is placed first and the literal with the highest
+// remaining decision level second.
+func (s *Solver) analyze(confl *clause) ([]int, int) {
+	learnt := []int{-1}
+	pathCount := 0
+	p := -1
+	index := len(s.trail) - 1
+
+	for {
+		if confl.learnt {
+			s.bumpClause(confl)
+		}
+		start := 0
+		if p != -1 {
+			start = 1
+		}
+		for _, q := range confl.lits[start:] {
+			v := q >> 1
+			if !s.seen[v] && s.level[v] > 0 {
+				s.bumpVar(v)
+				s.seen[v] = true
+				if s.level[v] >= s.decisionLevel() {
+					pathCount++
+				} else {
+					learnt = append(learnt, q)
+				}
+			}
+		}
+
+		for !s.seen[s.trail[index]>>1] {
+			index--
+		}
+		p = s.trail[index]
+		index--
+		confl = s.reason[p>>1]
+		s.seen[p>>1] = false
+		pathCount--
+		if pathCount == 0 {
+			break
+		}
+	}
+	learnt[0] = p ^ 1
+
+	minimized := make([]int, 1, len(learnt))
+	minimized[0] = learnt[0]
+	for _, q := range learnt[1:] {
+		if !s.redundant(q) {
+			minimized = append(minimized, q)
+		}
+	}
+	for _, q := range learnt {
+		s.seen[q>>1] = false
+	}
+	learnt = minimized
+
+	backtrackLevel := 0
+	if len(learnt) > 1 {
+		highest := 1
+		for i := 2; i < len(learnt); i++ {
+			if s.level[learnt[i]>>1] > s.level[learnt[highest]>>1] {
+				highest = i
+			}
+		}
+		learnt[1], learnt[highest] = learnt[highest], learnt[1]
+		backtrackLevel = s.level[learnt[1]>>1]
+	}
+
+	return learnt, backtrackLevel
+}
+
+// redundant reports whether a literal of the learnt clause is implied by the
+// other literals through its reason clause.
+func (s *Solver) redundant(lit int) bool {
+	r := s.reason[lit>>1]
+	if r == nil {
+		return false
+	}
+	for _, q := range r.lits[1:] {
+		v := q >> 1
+		if !s.seen[v] && s.level[v] > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// analyzeFinal collects the assumptions that imply the negation of p.
+func (s *Solver) analyzeFinal(p int) {
+	s.conflict = []int{toExternal(p ^ 1)}
+	if s.decisionLevel() == 0 {
+		return
+	}
+
+	s.seen[p>>1] = true
+	for i := len(s.trail) - 1; i >= s.trailLim[0]; i-- {
+		v := s.trail[i] >> 1
+		if !s.seen[v] {
+			continue
+		}
+		if s.reason[v] == nil {
+			if s.level[v] > 0 {
+				s.conflict = append(s.conflict, toExternal(s.trail[i]))
+			}
+		} else {
+			for _, q := range s.reason[v].lits[1:] {
+				if s.level[q>>1] > 0 {
+					s.seen[q>>1] = true
+				}
+			}
+		}
+		s.seen[v] = false
+	}
+	s.seen[p>>1] = false
+}
+
+func (s *Solver) pickBranchLit() int {
+	for !s.order.empty() {
+		v := s.order.pop()
+		if s.assigns[v] == 0 {
+			if s.polarity[v] {
+				return 2*v + 1
+			}
+			return 2 * v
+		}
+	}
+	return -1
+}
+
+func (s *Solver) enqueue(lit int, from *clause) {
+	v := lit >> 1
+	if lit&1 == 1 {
+		s.assigns[v] = -1
+	} else {
+		s.assigns[v] = 1
+	}
+	s.level[v] = s.decisionLevel()
+	s.reason[v] = from
+	s.trail = append(s.trail, lit)
+}
+
+func (s *Solver) cancelUntil(level int) {
+	if s.decisionLevel() <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trailLim[level]; i-- {
+		v := s.trail[i] >> 1
+		s.assigns[v] = 0
+		s.reason[v] = nil
+		s.polarity[v] = s.trail[i]&1 == 1
+		s.order.insert(v)
+	}
+	s.trail = s.trail[:s.trailLim[level]]
+	s.trailLim = s.trailLim[:level]
+	s.qhead = len(s.trail)
+}
+
+func (s *Solver) newDecisionLevel() {
+	s.trailLim = append(s.trailLim, len(s.trail))
+}
+
+func (s *Solver) decisionLevel() int {
+	return len(s.trailLim)
+}
+
+func (s *Solver) attach(c *clause) {
+	s.watches[c.lits[0]] = append(s.watches[c.lits[0]], c)
+	s.watches[c.lits[1]] = append(s.watches[c.lits[1]], c)
+}
+
+func (s *Solver) litValue(lit int) int8 {
+	value := s.assigns[lit>>1]
+	if lit&1 == 1 {
+		return -value
+	}
+	return value
+}
+
+func (s *Solver) bumpVar(v int) {
+	s.activity[v] += s.varInc
+	if s.activity[v] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] *= 1e-100
+		}
+		s.varInc *= 1e-100
+	}
+	s.order.update(v)
+}
+
+func (s *Solver) bumpClause(c *clause) {
+	c.activity += s.clauseInc
+	if c.activity > 1e20 {
+		for _, l := range s.learnts {
+			l.activity *= 1e-20
+		}
+		s.clauseInc *= 1e-20
+	}
+}
+
+func (s *Solver) locked(c *clause) bool {
+	v := c.lits[0] >> 1
+	return s.reason[v] == c && s.litValue(c.lits[0]) == 1
+}
+
+// reduceLearnts drops the less active half of the learnt clauses.
+func (s *Solver) reduceLearnts() {
+	sort.Slice(s.learnts, func(i, j int) bool {
+		return s.learnts[i].activity < s.learnts[j].activity
+	})
+	kept := s.learnts[:0]
+	half := len(s.learnts) / 2
+	for i, c := range s.learnts {
+		if i < half && len(c.lits) > 2 && !s.locked(c) {
+			c.deleted = true
+			continue
+		}
+		kept = append(kept, c)
+	}
+	s.learnts = kept
+	s.purgeWatches()
+	s.maxLearnts *= 1.1
+}
+
+// simplify removes clauses satisfied at the top level.
+func (s *Solver) simplify() {
+	removed := false
+	for _, set := range []*[]*clause{&s.clauses, &s.learnts} {
+		kept := (*set)[:0]
+		for _, c := range *set {
+			if s.satisfied(c) {
+				c.deleted = true
+				removed = true
+				continue
+			}
+			kept = append(kept, c)
+		}
+		*set = kept
+	}
+	if removed {
+		s.purgeWatches()
+	}
+}
+
+func (s *Solver) satisfied(c *clause) bool {
+	for _, l := range c.lits {
+		if s.litValue(l) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Solver) purgeWatches() {
+	for lit, ws := range s.watches {
+		kept := ws[:0]
+		for _, c := range ws {
+			if !c.deleted {
+				kept = append(kept, c)
+			}
+		}
+		s.watches[lit] = kept
+	}
+}
+
+// luby returns the i-th element of the Luby restart sequence with base y.
+func luby(y int, i int) int {
+	size, seq := 1, 0
+	for size < i+1 {
+		seq++
+		size = 2*size + 1
+	}
+	x := i
+	for size-1 != x {
+		size = (size - 1) >> 1
+		seq--
+		x = x % size
+	}
+	result := 1
+	for ; seq > 0; seq-- {
+		result *= y
+	}
+	return result
+}
+
+func toInternal(lit int) int {
+	if lit < 0 {
+		return 2*(-lit-1) + 1
+	}
+	return 2 * (lit - 1)
+}
+
+func toExternal(lit int) int {
+	if lit&1 == 1 {
+		return -(lit>>1 + 1)
+	}
+	return lit>>1 + 1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func containsInt(slice []int, item int) bool {
+	for _, s := range slice {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
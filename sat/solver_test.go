@@ -0,0 +1,117 @@
+package sat
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// randomCNF returns clauses of one to three literals over variables 1..vars.
+func randomCNF(r *rand.Rand, vars, clauses int) [][]int {
+	cnf := make([][]int, clauses)
+	for i := range cnf {
+		width := 1 + r.Intn(3)
+		for j := 0; j < width; j++ {
+			lit := 1 + r.Intn(vars)
+			if r.Intn(2) == 0 {
+				lit = -lit
+			}
+			cnf[i] = append(cnf[i], lit)
+		}
+	}
+	return cnf
+}
+
+func satisfies(assignment func(v int) bool, lits ...int) bool {
+	for _, lit := range lits {
+		if assignment(abs(lit)) == (lit > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// bruteForce reports whether an assignment over variables 1..vars satisfies
+// every clause and every unit literal.
+func bruteForce(cnf [][]int, vars int, units []int) bool {
+	for bits := 0; bits < 1<<vars; bits++ {
+		assignment := func(v int) bool { return bits&(1<<(v-1)) != 0 }
+		ok := true
+		for _, c := range cnf {
+			ok = ok && satisfies(assignment, c...)
+		}
+		for _, unit := range units {
+			ok = ok && satisfies(assignment, unit)
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSolveAgainstBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < 2000; round++ {
+		vars := 1 + r.Intn(8)
+		cnf := randomCNF(r, vars, r.Intn(5*vars))
+
+		s := NewSolver()
+		for v := 0; v < vars; v++ {
+			s.NewVar()
+		}
+		for _, c := range cnf {
+			s.AddClause(c...)
+		}
+
+		// the same solver answers several queries under different assumptions
+		for query := 0; query < 3; query++ {
+			assumptions := make([]int, 0)
+			if query > 0 {
+				for v := 1; v <= vars; v++ {
+					if r.Intn(3) == 0 {
+						assumptions = append(assumptions, v*(1-2*r.Intn(2)))
+					}
+				}
+			}
+
+			want := bruteForce(cnf, vars, assumptions)
+			if got := s.Solve(assumptions...); got != want {
+				t.Fatalf("%v under %v: solver says %t, brute force %t", cnf, assumptions, got, want)
+			}
+			if want {
+				for _, c := range cnf {
+					if !satisfies(s.Value, c...) {
+						t.Fatalf("%v: model %v falsifies %v", cnf, s.Model(), c)
+					}
+				}
+				for _, a := range assumptions {
+					if !satisfies(s.Value, a) {
+						t.Fatalf("%v: model %v violates assumption %d", cnf, s.Model(), a)
+					}
+				}
+				continue
+			}
+
+			conflict := s.Conflict()
+			for _, lit := range conflict {
+				if !containsInt(assumptions, lit) {
+					t.Fatalf("%v under %v: conflict %v is not a subset of the assumptions", cnf, assumptions, conflict)
+				}
+			}
+			if bruteForce(cnf, vars, conflict) {
+				t.Fatalf("%v under %v: conflict %v is satisfiable", cnf, assumptions, conflict)
+			}
+		}
+	}
+}
+
+func TestAddClauseDetectsContradiction(t *testing.T) {
+	s := NewSolver()
+	s.AddClause(1)
+	if s.AddClause(-1) {
+		t.Error("adding -1 after 1 should report unsatisfiability")
+	}
+	if s.Solve() {
+		t.Error("1 and -1 should be unsatisfiable")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/sat"
+	"strings"
+)
+
+// Satisfiable decides whether the expression can be made true, returning a
+// satisfying assignment over getAllIdentifiers(expression) when it can.
+func Satisfiable(expression ast.Expression) (bool, map[string]bool) {
+	solver := sat.NewSolver()
+	encoding := newCNFEncoding(solver, getAllIdentifiers(expression, nil))
+	encoding.assert(expression)
+
+	if !solver.Solve() {
+		return false, nil
+	}
+	return true, encoding.assignment()
+}
+
+func formatSatisfiability(expression ast.Expression) string {
+	satisfiable, assignment := Satisfiable(expression)
+	if !satisfiable {
+		return bold("unsatisfiable")
+	}
+	return fmt.Sprintf("%s %s", bold("satisfiable:"), formatAssignment(getAllIdentifiers(expression, nil), assignment))
+}
+
+func formatAssignment(idents []string, assignment map[string]bool) string {
+	if len(idents) == 0 {
+		return "{}"
+	}
+	parts := make([]string, 0, len(idents))
+	for _, ident := range idents {
+		parts = append(parts, fmt.Sprintf("%s = %s", ident, colourBool(assignment[ident])))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ModelIterator enumerates the satisfying assignments of an expression one at
+// a time, every model found is excluded from the next search by a blocking
+// clause over the enumerated identifiers.
+type ModelIterator struct {
+	solver      *sat.Solver
+	encoding    *cnfEncoding
+	identifiers []string
+	exhausted   bool
+}
+
+// Models returns an iterator over the models of the expression restricted to
+// the given identifiers, all identifiers of the expression are used when
+// projection is empty. Assignments that differ only outside the projection
+// are reported once.
+func Models(expression ast.Expression, projection []string) *ModelIterator {
+	identifiers := projection
+	if len(identifiers) == 0 {
+		identifiers = getAllIdentifiers(expression, nil)
+	}
+
+	solver := sat.NewSolver()
+	encoding := newCNFEncoding(solver, identifiers)
+	encoding.assert(expression)
+
+	return &ModelIterator{solver: solver, encoding: encoding, identifiers: identifiers}
+}
+
+// Next returns the next model, the second result is false once all models
+// have been reported.
+func (it *ModelIterator) Next() (map[string]bool, bool) {
+	if it.exhausted || !it.solver.Solve() {
+		it.exhausted = true
+		return nil, false
+	}
+
+	model := it.encoding.assignment()
+	blocking := make([]int, 0, len(it.identifiers))
+	for _, ident := range it.identifiers {
+		v := it.encoding.variables[ident]
+		if model[ident] {
+			blocking = append(blocking, -v)
+		} else {
+			blocking = append(blocking, v)
+		}
+	}
+	if !it.solver.AddClause(blocking...) {
+		it.exhausted = true
+	}
+
+	return model, true
+}
+
+// modelListLimit caps the number of models printed by the models statement.
+const modelListLimit = 64
+
+func formatModels(expression ast.Expression, projection []string) string {
+	identifiers := projection
+	if len(identifiers) == 0 {
+		identifiers = getAllIdentifiers(expression, nil)
+	}
+
+	it := Models(expression, projection)
+	lines := make([]string, 0)
+	for {
+		model, ok := it.Next()
+		if !ok {
+			break
+		}
+		if len(lines) == modelListLimit {
+			count := "count " + expression.Literal()
+			if len(projection) > 0 {
+				count += " over " + strings.Join(projection, ", ")
+			}
+			lines = append(lines, fmt.Sprintf("... more models, use \"%s\" for the total", count))
+			break
+		}
+		lines = append(lines, formatAssignment(identifiers, model))
+	}
+
+	if len(lines) == 0 {
+		return bold("unsatisfiable")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/egraph"
+	"github.com/terawatthour/logix/parser"
+	"github.com/terawatthour/logix/tokenizer"
+	"slices"
+	"strings"
+	"time"
+)
+
+// structuralEquations let the binary nodes of an e-graph reorder and
+// regroup conjunctions and disjunctions, which Simplify does through the
+// n-ary form instead of by rules.
+//
+// With both distributive laws enabled, distribution and expansion, the graph
+// grows without bound: each law builds terms the other applies to again,
+// and these equations add every reordering and regrouping of them. Even
+// a ^ b reaches the node limit within ten iterations. Orienting the
+// associativity equations does not help, as commutativity regroups the
+// terms just the same. A run stopped by a limit still extracts a formula
+// equivalent to the input, the cheapest one found so far.
+var structuralEquations = equations(
+	"A * B = B * A",
+	"A + B = B + A",
+	"A ^ B = B ^ A",
+	"A <-> B = B <-> A",
+	"(A * B) * C = A * (B * C)",
+	"A * (B * C) = (A * B) * C",
+	"(A + B) + C = A + (B + C)",
+	"A + (B + C) = (A + B) + C",
+)
+
+// defaultSaturationLimits bound the runs of the saturate statement.
+var defaultSaturationLimits = egraph.Limits{Nodes: 20000, Time: 2 * time.Second, Iterations: 30}
+
+// saturationRules compiles the structural equations and the equations of
+// the enabled rules of the evaluator, the built-in ones as well as those
+// defined by the user. Equations e-matching cannot use, such as one with a
+// bare metavariable as its pattern, are left out.
+func (e *Evaluator) saturationRules() []*egraph.Rule {
+	rules := make([]*egraph.Rule, 0)
+	seen := make(map[string]bool)
+	add := func(name string, equation Equation) {
+		key := equation.Pattern.Literal() + " = " + equation.Replacement.Literal()
+		if seen[key] {
+			return
+		}
+		if rule, err := egraph.NewRule(name, equation.Pattern, equation.Replacement); err == nil {
+			seen[key] = true
+			rules = append(rules, rule)
+		}
+	}
+
+	for _, equation := range structuralEquations {
+		add("structural", equation)
+	}
+	for _, rule := range e.simplificationRules {
+		if !rule.Enabled {
+			continue
+		}
+		for _, equation := range rule.Equations {
+			add(rule.Name, equation)
+		}
+	}
+	return rules
+}
+
+func parseRuleSide(source string) ast.Expression {
+	tok := tokenizer.NewTokenizer(source)
+	if err := tok.Tokenize(); err != nil {
+		panic(err)
+	}
+	expression, err := parser.NewParser(tok).ParseExpression()
+	if err != nil {
+		panic(err)
+	}
+	return expression
+}
+
+// Saturate adds the expression to an e-graph, applies the rules within the
+// limits and extracts the cheapest equivalent formula under the cost
+// function.
+func Saturate(expression ast.Expression, rules []*egraph.Rule, cost egraph.CostFunction, limits egraph.Limits) (ast.Expression, int, *egraph.Report) {
+	graph := egraph.New()
+	root := graph.Add(expression)
+	report := graph.Saturate(rules, limits)
+	result, value := graph.Extract(root, cost)
+	return result, value, report
+}
+
+func (e *Evaluator) formatSaturation(expression ast.Expression, model string) string {
+	if model == "" {
+		model = "literals"
+	}
+	cost, ok := egraph.CostModels[model]
+	if !ok {
+		known := make([]string, 0, len(egraph.CostModels))
+		for name := range egraph.CostModels {
+			known = append(known, name)
+		}
+		slices.Sort(known)
+		return fmt.Sprintf("unknown cost model %s, the known models are %s", model, strings.Join(known, ", "))
+	}
+
+	result, value, report := Saturate(expression, e.saturationRules(), cost, defaultSaturationLimits)
+	output := fmt.Sprintf("%s\ncost %d by %s, %d e-nodes in %d classes after %d iteration(s), %s",
+		result.Literal(), value, model, report.Nodes, report.Classes, report.Iterations, report.Stop)
+	if report.Stop == egraph.NodeLimit && e.ruleEnabled("distribution") && e.ruleEnabled("expansion") {
+		output += "\nthe distributive laws grow the graph without bound, \".rules disable expansion\" may let it saturate"
+	}
+	return output
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/egraph"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestSaturateKeepsEquivalence(t *testing.T) {
+	rules := NewEvaluator().saturationRules()
+	limits := egraph.Limits{Nodes: 500, Iterations: 4}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		data := make([]byte, r.Intn(16))
+		r.Read(data)
+		expression := fuzzExpression(data)
+		for model, cost := range egraph.CostModels {
+			result, _, report := Saturate(expression, rules, cost, limits)
+			if equivalent, _ := Equivalent(expression, result); !equivalent {
+				t.Fatalf("%s saturated by %s to the inequivalent %s, %s", expression.Literal(), model, result.Literal(), report.Stop)
+			}
+		}
+	}
+}
+
+func TestSaturationNodeLimitHint(t *testing.T) {
+	e := NewEvaluator()
+	expression := parseRuleSide("a ^ b")
+	if output := e.formatSaturation(expression, "depth"); !strings.Contains(output, "node limit") || !strings.Contains(output, "disable expansion") {
+		t.Errorf("a ^ b did not hit the node limit with a hint:\n%s", output)
+	}
+
+	if err := e.EnableRule("expansion", false); err != nil {
+		t.Fatal(err)
+	}
+	if output := e.formatSaturation(expression, "depth"); !strings.Contains(output, "saturated") {
+		t.Errorf("a ^ b did not saturate without expansion:\n%s", output)
+	}
+}
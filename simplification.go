@@ -1,43 +1,21 @@
 package main
 
 import (
-	"fmt"
 	"github.com/terawatthour/logix/ast"
 )
 
-var DEBUG = false
-
-func debugPrint(messages ...any) {
-	if DEBUG {
-		fmt.Println(messages...)
-	}
-}
-
-func checkForRightOrLeft(fn func(expression ast.Expression) (bool, ast.Expression), base *ast.InfixExpression) (bool, ast.Expression) {
-	if matched, expr := fn(base.Left); matched {
-		return true, expr
-	}
-	if matched, expr := fn(base.Right); matched {
-		return true, expr
-	}
-	return false, base
-
-}
+// The rules below work on flattened formulas, see ast.Flatten: conjunctions
+// and disjunctions are *ast.NaryExpression with sorted operands, so a rule
+// matching some of the operands matches them wherever they are in the chain.
 
 // IdempotenceRule
 // a + a = a
 // a * a = a
 func IdempotenceRule(expression ast.Expression) (bool, ast.Expression) {
-	if expression.Type() != "infix" {
-		return false, expression
-	}
-	expr := expression.(*ast.InfixExpression)
-	if (expr.Action != "or" && expr.Action != "and") || expr.Left.Literal() != expr.Right.Literal() {
+	if expression.Type() != "nary" {
 		return false, expression
 	}
-
-	debugPrint("turning", expression.Literal(), "into", expr.Left.Literal(), "by idempotence")
-	return true, &ast.Identifier{Value: expr.Left.Literal()}
+	return withoutDuplicates(expression.(*ast.NaryExpression))
 }
 
 func BiconditionalRule(expression ast.Expression) (bool, ast.Expression) {
@@ -54,295 +32,148 @@ func BiconditionalRule(expression ast.Expression) (bool, ast.Expression) {
 		return true, &ast.Boolean{Value: true}
 	}
 
-	return true, &ast.InfixExpression{
-		Op:     "*",
-		Action: "and",
-		Left: &ast.InfixExpression{
+	return true, joinOperands([]ast.Expression{
+		&ast.InfixExpression{
 			Op:     "->",
 			Action: "->",
 			Left:   expr.Left,
 			Right:  expr.Right,
 		},
-		Right: &ast.InfixExpression{
+		&ast.InfixExpression{
 			Op:     "->",
 			Action: "->",
-			Left:   expr.Right,
-			Right:  expr.Left,
+			Left:   ast.Clone(expr.Right),
+			Right:  ast.Clone(expr.Left),
 		},
-	}
+	}, "and")
 }
 
 // NegatedAlternativeRule
 // a + !a = 1
+// a + b + !a = b + 1
 func NegatedAlternativeRule(expression ast.Expression) (bool, ast.Expression) {
-	if expression.Type() != "infix" {
+	if expression.Type() != "nary" {
 		return false, expression
 	}
-
-	expr := expression.(*ast.InfixExpression)
+	expr := expression.(*ast.NaryExpression)
 	if expr.Action != "or" {
 		return false, expression
 	}
-
-	if matched, expr := negatedAlternativeRule(expr.Left, expr.Right); matched {
-		debugPrint("turning", expression.Literal(), "into", expr.Literal())
-		return true, expr
-	}
-
-	return false, expression
-}
-
-func negatedAlternativeRule(left ast.Expression, right ast.Expression) (bool, ast.Expression) {
-	fmt.Println(left.Literal(), right.Literal())
-	if left.Type() == "identifier" && right.Type() == "prefix" {
-		if left.Literal() == right.Literal()[1:] {
-			return true, &ast.Boolean{Value: true}
-		}
-	} else if left.Type() == "prefix" && right.Type() == "identifier" {
-		if right.Literal() == left.Literal()[1:] {
-			return true, &ast.Boolean{Value: true}
-		}
-	}
-
-	if left.Type() == "infix" && (right.Type() == "identifier" || right.Type() == "prefix") {
-		expr := left.(*ast.InfixExpression)
-		if expr.Action == "or" {
-			debugPrint("checking", expr.Left.Literal(), right.Literal())
-			if matched, _ := negatedAlternativeRule(expr.Left, right); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-			if matched, _ := negatedAlternativeRule(expr.Right, right); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-		}
-	} else if (left.Type() == "identifier" || left.Type() == "prefix") && right.Type() == "infix" {
-		expr := right.(*ast.InfixExpression)
-		if expr.Action == "or" {
-			if matched, _ := negatedAlternativeRule(left, expr.Left); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-			if matched, _ := negatedAlternativeRule(left, expr.Right); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-		}
-	} else if left.Type() == "infix" && right.Type() == "infix" {
-		leftExpr := left.(*ast.InfixExpression)
-		rightExpr := right.(*ast.InfixExpression)
-		if leftExpr.Action == "or" && rightExpr.Action == "or" {
-			if matched, _ := negatedAlternativeRule(leftExpr.Left, rightExpr.Left); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-			if matched, _ := negatedAlternativeRule(leftExpr.Left, rightExpr.Right); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-			if matched, _ := negatedAlternativeRule(leftExpr.Right, rightExpr.Left); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-			if matched, _ := negatedAlternativeRule(leftExpr.Right, rightExpr.Right); matched {
-				return true, &ast.Boolean{Value: true}
-			}
-		}
-	}
-
-	return false, nil
+	return replaceComplementaryPair(expr, true)
 }
 
 // DuplicateAlternativeRule
 // a + a = a
 // a + b + a = a + b
 func DuplicateAlternativeRule(expression ast.Expression) (bool, ast.Expression) {
-	if expression.Type() != "infix" {
+	if expression.Type() != "nary" {
 		return false, expression
 	}
-
-	expr := expression.(*ast.InfixExpression)
+	expr := expression.(*ast.NaryExpression)
 	if expr.Action != "or" {
 		return false, expression
 	}
-
-	if matched, expr := duplicateAlternativeRule(expr.Left, expr.Right); matched {
-		debugPrint("turning", expression.Literal(), "into", expr.Literal())
-		return true, expr
-	}
-	return false, expression
+	return withoutDuplicates(expr)
 }
 
-func duplicateAlternativeRule(left ast.Expression, right ast.Expression) (bool, ast.Expression) {
-	if left.Type() == "identifier" && right.Type() == "identifier" {
-		if left.Literal() == right.Literal() {
-			return true, left
+// withoutDuplicates keeps the first of equal operands.
+func withoutDuplicates(expr *ast.NaryExpression) (bool, ast.Expression) {
+	operands := make([]ast.Expression, 0, len(expr.Operands))
+	seen := make([]string, 0, len(expr.Operands))
+	for _, operand := range expr.Operands {
+		if contains(seen, operand.Literal()) {
+			continue
 		}
+		seen = append(seen, operand.Literal())
+		operands = append(operands, operand)
 	}
-	if left.Type() == "prefix" && right.Type() == "prefix" {
-		leftExpr := left.(*ast.PrefixExpression)
-		rightExpr := right.(*ast.PrefixExpression)
-		if leftExpr.Op == "!" && rightExpr.Op == "!" {
-			if leftExpr.Right.Type() == "identifier" && rightExpr.Right.Type() == "identifier" {
-				if leftExpr.Right.Literal() == rightExpr.Right.Literal() {
-					return true, left
-				}
-			}
-		}
+
+	if len(operands) == len(expr.Operands) {
+		return false, expr
 	}
+	return true, joinOperands(operands, expr.Action)
+}
 
-	if left.Type() == "infix" && (right.Type() == "identifier" || right.Type() == "prefix") {
-		expr := left.(*ast.InfixExpression)
-		if expr.Action == "or" {
-			if matched, _ := duplicateAlternativeRule(expr.Left, right); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   left,
-					Right:  &ast.Boolean{Value: false},
-				}
-			}
-			if matched, _ := duplicateAlternativeRule(expr.Right, right); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   left,
-					Right:  &ast.Boolean{Value: false},
-				}
+// replaceComplementaryPair replaces the first pair of complementary operands
+// with the constant they reduce to.
+func replaceComplementaryPair(expr *ast.NaryExpression, value bool) (bool, ast.Expression) {
+	for i, a := range expr.Operands {
+		for j := i + 1; j < len(expr.Operands); j++ {
+			if !complementary(a, expr.Operands[j]) {
+				continue
 			}
+			operands := withoutIndices(expr.Operands, i, j)
+			return true, joinOperands(append(operands, &ast.Boolean{Value: value}), expr.Action)
 		}
-	} else if (left.Type() == "identifier" || left.Type() == "prefix") && right.Type() == "infix" {
-		expr := right.(*ast.InfixExpression)
-		if expr.Action == "or" {
-			if matched, expr := duplicateAlternativeRule(left, expr.Left); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   expr,
-					Right:  &ast.Boolean{Value: false},
-				}
-			}
-			if matched, expr := duplicateAlternativeRule(left, expr.Right); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   &ast.Boolean{Value: false},
-					Right:  expr,
-				}
-			}
+	}
+	return false, expr
+}
+
+// collectOperands lists the operands of a conjunction or disjunction with the
+// given action, an expression of another kind is a chain of one.
+func collectOperands(expression ast.Expression, action string) []ast.Expression {
+	switch expr := expression.(type) {
+	case *ast.NaryExpression:
+		if expr.Action == action {
+			return expr.Operands
 		}
-	} else if left.Type() == "infix" && right.Type() == "infix" {
-		leftExpr := left.(*ast.InfixExpression)
-		rightExpr := right.(*ast.InfixExpression)
-		if leftExpr.Action == "or" && rightExpr.Action == "or" {
-			if matched, expr := duplicateAlternativeRule(leftExpr.Left, rightExpr.Left); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   expr,
-					Right:  &ast.Boolean{Value: false},
-				}
-			}
-			if matched, expr := duplicateAlternativeRule(leftExpr.Left, rightExpr.Right); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   expr,
-					Right:  &ast.Boolean{Value: false},
-				}
-			}
-			if matched, expr := duplicateAlternativeRule(leftExpr.Right, rightExpr.Left); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   expr,
-					Right:  &ast.Boolean{Value: false},
-				}
-			}
-			if matched, expr := duplicateAlternativeRule(leftExpr.Right, rightExpr.Right); matched {
-				return true, &ast.InfixExpression{
-					Op:     "+",
-					Action: "or",
-					Left:   expr,
-					Right:  &ast.Boolean{Value: false},
-				}
-			}
+	case *ast.InfixExpression:
+		if expr.Action == action {
+			return append(collectOperands(expr.Left, action), collectOperands(expr.Right, action)...)
 		}
 	}
+	return []ast.Expression{expression}
+}
 
-	return false, nil
+// joinOperands builds the flattened conjunction or disjunction of the
+// operands, see ast.NewNary.
+func joinOperands(operands []ast.Expression, action string) ast.Expression {
+	return ast.NewNary(action, operands)
 }
 
 // NegatedConjunctionRule
 // a * !a = 0
+// a * b * !a = b * 0
 func NegatedConjunctionRule(expression ast.Expression) (bool, ast.Expression) {
-	if expression.Type() != "infix" {
+	if expression.Type() != "nary" {
 		return false, expression
 	}
-	expr := expression.(*ast.InfixExpression)
+	expr := expression.(*ast.NaryExpression)
 	if expr.Action != "and" {
 		return false, expression
 	}
-	if expr.Left.Type() == "identifier" && expr.Right.Type() == "prefix" {
-		prefix := expr.Right.(*ast.PrefixExpression)
-		if prefix.Op == "!" && prefix.Right.Type() == "identifier" {
-			if expr.Left.Literal() == prefix.Right.Literal() {
-				return true, &ast.Boolean{Value: false}
-			}
-		}
-	} else if expr.Right.Type() == "identifier" && expr.Left.Type() == "prefix" {
-		prefix := expr.Left.(*ast.PrefixExpression)
-		if prefix.Op == "!" && prefix.Right.Type() == "identifier" {
-			if expr.Right.Literal() == prefix.Right.Literal() {
-				return true, &ast.Boolean{Value: false}
-			}
-		}
-	}
-
-	return false, expression
+	return replaceComplementaryPair(expr, false)
 }
 
 // IdentityRule
 // a + 0 = a
+// a + 1 = 1
 // a * 1 = a
 // a * 0 = 0
 func IdentityRule(expression ast.Expression) (bool, ast.Expression) {
-	if expression.Type() != "infix" {
+	if expression.Type() != "nary" {
 		return false, expression
 	}
-	expr := expression.(*ast.InfixExpression)
-	if expr.Action == "and" {
-		if matched, expr := checkForRightOrLeft(func(expression ast.Expression) (bool, ast.Expression) {
-			if expression.Type() == "boolean" && expression.Literal() == "0" {
-				return true, &ast.Boolean{Value: false}
-			}
-			return false, expression
-		}, expr); matched {
-			return true, expr
-		}
-
-		if expr.Left.Literal() == "1" {
-			return true, expr.Right
-		}
+	expr := expression.(*ast.NaryExpression)
 
-		if expr.Right.Literal() == "1" {
-			return true, expr.Left
-		}
-
-	} else if expr.Action == "or" {
-		if matched, expr := checkForRightOrLeft(func(expression ast.Expression) (bool, ast.Expression) {
-			if expression.Type() == "boolean" && expression.Literal() == "1" {
-				return true, &ast.Boolean{Value: true}
+	// true annihilates a disjunction and false a conjunction, the other
+	// constant is neutral
+	annihilator := expr.Action == "or"
+	operands := make([]ast.Expression, 0, len(expr.Operands))
+	for _, operand := range expr.Operands {
+		if boolean, ok := operand.(*ast.Boolean); ok {
+			if boolean.Value == annihilator {
+				return true, &ast.Boolean{Value: annihilator}
 			}
-			return false, expression
-		}, expr); matched {
-			return true, expr
-		}
-
-		if expr.Left.Literal() == "0" {
-			return true, expr.Right
-		}
-
-		if expr.Right.Literal() == "0" {
-			return true, expr.Left
+			continue
 		}
+		operands = append(operands, operand)
 	}
 
-	return false, expression
+	if len(operands) == len(expr.Operands) {
+		return false, expression
+	}
+	return true, joinOperands(operands, expr.Action)
 }
 
 func ImplicationRule(expression ast.Expression) (bool, ast.Expression) {
@@ -354,12 +185,10 @@ func ImplicationRule(expression ast.Expression) (bool, ast.Expression) {
 		return false, expression
 	}
 
-	return true, &ast.InfixExpression{
-		Op:     "+",
-		Action: "or",
-		Left:   &ast.PrefixExpression{Op: "!", Right: expr.Left},
-		Right:  expr.Right,
-	}
+	return true, joinOperands([]ast.Expression{
+		&ast.PrefixExpression{Op: "!", Right: expr.Left},
+		expr.Right,
+	}, "or")
 }
 
 func DeMorganRule(expression ast.Expression) (bool, ast.Expression) {
@@ -370,27 +199,47 @@ func DeMorganRule(expression ast.Expression) (bool, ast.Expression) {
 	if expr.Op != "!" {
 		return false, expression
 	}
-	if expr.Right.Type() != "infix" {
+	if expr.Right.Type() != "nary" {
 		return false, expression
 	}
-	infix := expr.Right.(*ast.InfixExpression)
-	if infix.Action != "and" && infix.Action != "or" {
-		return false, expression
+	nary := expr.Right.(*ast.NaryExpression)
+
+	operands := make([]ast.Expression, 0, len(nary.Operands))
+	for _, operand := range nary.Operands {
+		operands = append(operands, &ast.PrefixExpression{Op: "!", Right: operand})
 	}
+	return true, joinOperands(operands, dualAction(nary.Action))
+}
 
-	var op string
-	if infix.Action == "and" {
-		op = "+"
-	} else {
-		op = "*"
+// NegatedConstantRule
+// !0 = 1
+// !1 = 0
+func NegatedConstantRule(expression ast.Expression) (bool, ast.Expression) {
+	if expression.Type() != "prefix" {
+		return false, expression
+	}
+	expr := expression.(*ast.PrefixExpression)
+	if expr.Op != "!" || expr.Right.Type() != "boolean" {
+		return false, expression
 	}
+	return true, &ast.Boolean{Value: !expr.Right.(*ast.Boolean).Value}
+}
 
-	return true, &ast.InfixExpression{
-		Op:     op,
-		Action: infix.Action,
-		Left:   &ast.PrefixExpression{Op: "!", Right: infix.Left},
-		Right:  &ast.PrefixExpression{Op: "!", Right: infix.Right},
+// ExclusiveOrRule
+// a ^ b = a * !b + !a * b
+func ExclusiveOrRule(expression ast.Expression) (bool, ast.Expression) {
+	if expression.Type() != "infix" {
+		return false, expression
 	}
+	expr := expression.(*ast.InfixExpression)
+	if expr.Action != "xor" {
+		return false, expression
+	}
+
+	return true, joinOperands([]ast.Expression{
+		joinOperands([]ast.Expression{expr.Left, &ast.PrefixExpression{Op: "!", Right: expr.Right}}, "and"),
+		joinOperands([]ast.Expression{&ast.PrefixExpression{Op: "!", Right: ast.Clone(expr.Left)}, ast.Clone(expr.Right)}, "and"),
+	}, "or")
 }
 
 func DoubleNegationRule(expression ast.Expression) (bool, ast.Expression) {
@@ -411,3 +260,296 @@ func DoubleNegationRule(expression ast.Expression) (bool, ast.Expression) {
 
 	return true, prefix.Right
 }
+
+// dualAction swaps conjunction and disjunction.
+func dualAction(action string) string {
+	if action == "and" {
+		return "or"
+	}
+	return "and"
+}
+
+func sameExpression(a ast.Expression, b ast.Expression) bool {
+	return a.Literal() == b.Literal()
+}
+
+// complementary reports whether one expression is the negation of the other.
+func complementary(a ast.Expression, b ast.Expression) bool {
+	if prefix, ok := a.(*ast.PrefixExpression); ok && prefix.Op == "!" && sameExpression(prefix.Right, b) {
+		return true
+	}
+	if prefix, ok := b.(*ast.PrefixExpression); ok && prefix.Op == "!" && sameExpression(prefix.Right, a) {
+		return true
+	}
+	return false
+}
+
+// containsExpression reports whether an operand equal to the expression is
+// among the operands.
+func containsExpression(operands []ast.Expression, expression ast.Expression) bool {
+	for _, operand := range operands {
+		if sameExpression(operand, expression) {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutExpressions removes one occurrence of each of the removed operands.
+func withoutExpressions(operands []ast.Expression, removed []ast.Expression) []ast.Expression {
+	result := make([]ast.Expression, len(operands))
+	copy(result, operands)
+	for _, r := range removed {
+		for i, operand := range result {
+			if sameExpression(operand, r) {
+				result = append(result[:i], result[i+1:]...)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// withoutIndices copies the operands except those at the given positions.
+func withoutIndices(operands []ast.Expression, indices ...int) []ast.Expression {
+	result := make([]ast.Expression, 0, len(operands))
+	for i, operand := range operands {
+		if !contains(indices, i) {
+			result = append(result, operand)
+		}
+	}
+	return result
+}
+
+// sameOperands reports whether two operand lists are equal as multisets.
+func sameOperands(a []ast.Expression, b []ast.Expression) bool {
+	return len(a) == len(b) && len(withoutExpressions(a, b)) == 0
+}
+
+// naryOperands returns a conjunction or disjunction, and nil for other
+// expressions.
+func naryOperands(expression ast.Expression) *ast.NaryExpression {
+	expr, ok := expression.(*ast.NaryExpression)
+	if !ok || (expr.Action != "or" && expr.Action != "and") {
+		return nil
+	}
+	return expr
+}
+
+// AbsorptionRule
+// a + a * b = a
+// a * (a + b) = a
+func AbsorptionRule(expression ast.Expression) (bool, ast.Expression) {
+	expr := naryOperands(expression)
+	if expr == nil {
+		return false, expression
+	}
+	inner := dualAction(expr.Action)
+	for i, absorbing := range expr.Operands {
+		small := collectOperands(absorbing, inner)
+		for j, absorbed := range expr.Operands {
+			large := collectOperands(absorbed, inner)
+			if i == j || len(large) <= len(small) || len(withoutExpressions(small, large)) > 0 {
+				continue
+			}
+			return true, joinOperands(withoutIndices(expr.Operands, j), expr.Action)
+		}
+	}
+	return false, expression
+}
+
+// ComplementAbsorptionRule
+// a * (!a + b) = a * b
+// a + !a * b = a + b
+func ComplementAbsorptionRule(expression ast.Expression) (bool, ast.Expression) {
+	expr := naryOperands(expression)
+	if expr == nil {
+		return false, expression
+	}
+	inner := dualAction(expr.Action)
+	for i, operand := range expr.Operands {
+		for j, other := range expr.Operands {
+			if i == j {
+				continue
+			}
+			operands := collectOperands(other, inner)
+			remaining := make([]ast.Expression, 0, len(operands))
+			for _, o := range operands {
+				if !complementary(operand, o) {
+					remaining = append(remaining, o)
+				}
+			}
+			if len(remaining) < len(operands) && len(remaining) > 0 {
+				rest := withoutIndices(expr.Operands, j)
+				return true, joinOperands(append(rest, joinOperands(remaining, inner)), expr.Action)
+			}
+		}
+	}
+	return false, expression
+}
+
+// DistributionRule factors out common operands
+// a * b + a * c = a * (b + c)
+// (a + b) * (a + c) = a + b * c
+func DistributionRule(expression ast.Expression) (bool, ast.Expression) {
+	expr := naryOperands(expression)
+	if expr == nil {
+		return false, expression
+	}
+	inner := dualAction(expr.Action)
+	for i := range expr.Operands {
+		for j := i + 1; j < len(expr.Operands); j++ {
+			left := collectOperands(expr.Operands[i], inner)
+			right := collectOperands(expr.Operands[j], inner)
+			if len(left) < 2 || len(right) < 2 {
+				continue
+			}
+
+			common := make([]ast.Expression, 0)
+			for _, operand := range left {
+				if containsExpression(right, operand) && !containsExpression(common, operand) {
+					common = append(common, operand)
+				}
+			}
+			restLeft := withoutExpressions(left, common)
+			restRight := withoutExpressions(right, common)
+			if len(common) == 0 || len(restLeft) == 0 || len(restRight) == 0 {
+				continue
+			}
+
+			factored := joinOperands([]ast.Expression{joinOperands(restLeft, inner), joinOperands(restRight, inner)}, expr.Action)
+			rest := withoutIndices(expr.Operands, i, j)
+			return true, joinOperands(append(rest, joinOperands(append(common, factored), inner)), expr.Action)
+		}
+	}
+	return false, expression
+}
+
+// ExpansionRule distributes, it grows the formula and is only kept when the
+// result simplifies to something cheaper
+// a * (b + c) = a * b + a * c
+// a + b * c = (a + b) * (a + c)
+func ExpansionRule(expression ast.Expression) (bool, ast.Expression) {
+	expr := naryOperands(expression)
+	if expr == nil {
+		return false, expression
+	}
+	inner := dualAction(expr.Action)
+	for i, operand := range expr.Operands {
+		sum, ok := operand.(*ast.NaryExpression)
+		if !ok || sum.Action != inner {
+			continue
+		}
+		rest := withoutIndices(expr.Operands, i)
+		terms := make([]ast.Expression, 0, len(sum.Operands))
+		for _, term := range sum.Operands {
+			operands := make([]ast.Expression, 0, len(rest)+1)
+			for _, r := range rest {
+				operands = append(operands, ast.Clone(r))
+			}
+			terms = append(terms, joinOperands(append(operands, term), expr.Action))
+		}
+		return true, joinOperands(terms, inner)
+	}
+	return false, expression
+}
+
+// ComplementFactoringRule
+// a * b + a * !b = a
+// (a + b) * (a + !b) = a
+func ComplementFactoringRule(expression ast.Expression) (bool, ast.Expression) {
+	expr := naryOperands(expression)
+	if expr == nil {
+		return false, expression
+	}
+	inner := dualAction(expr.Action)
+	for i := range expr.Operands {
+		for j := i + 1; j < len(expr.Operands); j++ {
+			left := collectOperands(expr.Operands[i], inner)
+			right := collectOperands(expr.Operands[j], inner)
+			if len(left) < 2 || len(left) != len(right) {
+				continue
+			}
+
+			for _, l := range left {
+				for _, r := range right {
+					if !complementary(l, r) {
+						continue
+					}
+					common := withoutExpressions(left, []ast.Expression{l})
+					if sameOperands(common, withoutExpressions(right, []ast.Expression{r})) {
+						rest := withoutIndices(expr.Operands, i, j)
+						return true, joinOperands(append(rest, joinOperands(common, inner)), expr.Action)
+					}
+				}
+			}
+		}
+	}
+	return false, expression
+}
+
+// ConsensusRule
+// a * b + !a * c + b * c = a * b + !a * c
+// (a + b) * (!a + c) * (b + c) = (a + b) * (!a + c)
+func ConsensusRule(expression ast.Expression) (bool, ast.Expression) {
+	expr := naryOperands(expression)
+	if expr == nil {
+		return false, expression
+	}
+	inner := dualAction(expr.Action)
+	terms := expr.Operands
+	if len(terms) < 3 {
+		return false, expression
+	}
+
+	for i := range terms {
+		for j := range terms {
+			if i == j {
+				continue
+			}
+			first := collectOperands(terms[i], inner)
+			second := collectOperands(terms[j], inner)
+			for _, x := range first {
+				for _, y := range second {
+					if !complementary(x, y) {
+						continue
+					}
+					consensus := withoutExpressions(first, []ast.Expression{x})
+					for _, operand := range withoutExpressions(second, []ast.Expression{y}) {
+						if !containsExpression(consensus, operand) {
+							consensus = append(consensus, operand)
+						}
+					}
+					if len(consensus) == 0 {
+						continue
+					}
+					for k, term := range terms {
+						if k == i || k == j || len(withoutExpressions(consensus, collectOperands(term, inner))) > 0 {
+							continue
+						}
+						return true, joinOperands(withoutIndices(terms, k), expr.Action)
+					}
+				}
+			}
+		}
+	}
+	return false, expression
+}
+
+// expressionCost measures a formula by its number of nodes in binary form,
+// guarded rules must lower it.
+func expressionCost(expression ast.Expression) int {
+	switch expr := expression.(type) {
+	case *ast.PrefixExpression:
+		return 1 + expressionCost(expr.Right)
+	case *ast.InfixExpression:
+		return 1 + expressionCost(expr.Left) + expressionCost(expr.Right)
+	case *ast.NaryExpression:
+		cost := len(expr.Operands) - 1
+		for _, operand := range expr.Operands {
+			cost += expressionCost(operand)
+		}
+		return cost
+	}
+	return 1
+}
@@ -0,0 +1,400 @@
+package smtlib
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"github.com/terawatthour/logix/tokenizer"
+	"strings"
+	"unicode"
+)
+
+// Script is the Boolean content of an SMT-LIB 2 script.
+type Script struct {
+	// Declarations maps declared symbols to the logix identifiers they were
+	// given, symbols that are not valid identifiers are renamed.
+	Declarations map[string]string
+	Identifiers  []string
+	Assertions   []ast.Expression
+}
+
+// Conjunction returns the conjunction of all assertions, true when there are none.
+func (s *Script) Conjunction() ast.Expression {
+	if len(s.Assertions) == 0 {
+		return &ast.Boolean{Value: true}
+	}
+	result := s.Assertions[0]
+	for _, assertion := range s.Assertions[1:] {
+		result = &ast.InfixExpression{Op: "*", Action: "and", Left: result, Right: assertion}
+	}
+	return result
+}
+
+type sexpr struct {
+	atom   string
+	quoted bool
+	list   []*sexpr
+	isList bool
+	line   int
+}
+
+func (s *sexpr) String() string {
+	if !s.isList {
+		if s.quoted {
+			return "|" + s.atom + "|"
+		}
+		return s.atom
+	}
+	parts := make([]string, 0, len(s.list))
+	for _, item := range s.list {
+		parts = append(parts, item.String())
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (s *sexpr) isAtom(name string) bool {
+	return !s.isList && !s.quoted && s.atom == name
+}
+
+// Read parses the Core theory Boolean fragment of an SMT-LIB 2 script:
+// Bool constants, the functions and, or, not, =>, xor, =, distinct and ite,
+// let bindings and nullary define-fun.
+func Read(source string) (*Script, error) {
+	expressions, err := readAll(source)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reader{
+		script: &Script{Declarations: make(map[string]string)},
+		taken:  make(map[string]bool),
+		global: make(map[string]ast.Expression),
+	}
+	for _, command := range expressions {
+		if err := r.command(command); err != nil {
+			return nil, fmt.Errorf("line %d: %w", command.line, err)
+		}
+	}
+	return r.script, nil
+}
+
+type reader struct {
+	script *Script
+	taken  map[string]bool
+	global map[string]ast.Expression
+}
+
+func (r *reader) command(command *sexpr) error {
+	if !command.isList || len(command.list) == 0 || command.list[0].isList {
+		return fmt.Errorf("expected a command, got %s", command)
+	}
+	args := command.list[1:]
+
+	switch command.list[0].atom {
+	case "set-logic", "set-info", "set-option", "check-sat", "get-model", "get-info", "exit":
+		return nil
+	case "declare-const":
+		if len(args) != 2 || args[0].isList {
+			return fmt.Errorf("malformed %s", command)
+		}
+		if !args[1].isAtom("Bool") {
+			return fmt.Errorf("only Bool constants are supported, got %s", args[1])
+		}
+		return r.declare(args[0].atom)
+	case "declare-fun":
+		if len(args) != 3 || args[0].isList || !args[1].isList {
+			return fmt.Errorf("malformed %s", command)
+		}
+		if len(args[1].list) != 0 || !args[2].isAtom("Bool") {
+			return fmt.Errorf("only nullary Bool functions are supported, got %s", command)
+		}
+		return r.declare(args[0].atom)
+	case "define-fun":
+		if len(args) != 4 || args[0].isList || !args[1].isList {
+			return fmt.Errorf("malformed %s", command)
+		}
+		if len(args[1].list) != 0 || !args[2].isAtom("Bool") {
+			return fmt.Errorf("only nullary Bool definitions are supported, got %s", command)
+		}
+		body, err := r.term(args[3], nil)
+		if err != nil {
+			return err
+		}
+		r.global[args[0].atom] = body
+		return nil
+	case "assert":
+		if len(args) != 1 {
+			return fmt.Errorf("malformed %s", command)
+		}
+		assertion, err := r.term(args[0], nil)
+		if err != nil {
+			return err
+		}
+		r.script.Assertions = append(r.script.Assertions, assertion)
+		return nil
+	}
+
+	return fmt.Errorf("unsupported command %s", command.list[0])
+}
+
+func (r *reader) declare(symbol string) error {
+	if _, ok := r.script.Declarations[symbol]; ok {
+		return fmt.Errorf("%s is already declared", symbol)
+	}
+	name := identifierFor(symbol)
+	for suffix := 1; r.taken[name]; suffix++ {
+		name = fmt.Sprintf("%s_%d", identifierFor(symbol), suffix)
+	}
+	r.taken[name] = true
+	r.script.Declarations[symbol] = name
+	r.script.Identifiers = append(r.script.Identifiers, name)
+	return nil
+}
+
+// term translates an SMT-LIB term, scope holds the let bindings in effect.
+func (r *reader) term(term *sexpr, scope map[string]ast.Expression) (ast.Expression, error) {
+	if !term.isList {
+		if !term.quoted {
+			switch term.atom {
+			case "true":
+				return &ast.Boolean{Value: true}, nil
+			case "false":
+				return &ast.Boolean{Value: false}, nil
+			}
+		}
+		if bound, ok := scope[term.atom]; ok {
+			return ast.Clone(bound), nil
+		}
+		if defined, ok := r.global[term.atom]; ok {
+			return ast.Clone(defined), nil
+		}
+		if name, ok := r.script.Declarations[term.atom]; ok {
+			return &ast.Identifier{Value: name}, nil
+		}
+		return nil, fmt.Errorf("unknown symbol %s", term)
+	}
+
+	if len(term.list) == 0 || term.list[0].isList {
+		return nil, fmt.Errorf("unsupported term %s", term)
+	}
+	head := term.list[0].atom
+
+	if head == "let" {
+		return r.let(term, scope)
+	}
+	if head == "!" {
+		if len(term.list) < 2 {
+			return nil, fmt.Errorf("malformed %s", term)
+		}
+		return r.term(term.list[1], scope)
+	}
+
+	args := make([]ast.Expression, 0, len(term.list)-1)
+	for _, arg := range term.list[1:] {
+		expression, err := r.term(arg, scope)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, expression)
+	}
+
+	switch head {
+	case "not":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("not expects one argument in %s", term)
+		}
+		return negation(args[0]), nil
+	case "and":
+		if len(args) == 0 {
+			return &ast.Boolean{Value: true}, nil
+		}
+		return leftAssociative("*", args), nil
+	case "or":
+		if len(args) == 0 {
+			return &ast.Boolean{Value: false}, nil
+		}
+		return leftAssociative("+", args), nil
+	case "xor":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("xor expects at least two arguments in %s", term)
+		}
+		return leftAssociative("^", args), nil
+	case "=>":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("=> expects at least two arguments in %s", term)
+		}
+		result := args[len(args)-1]
+		for i := len(args) - 2; i >= 0; i-- {
+			result = infix("->", args[i], result)
+		}
+		return result, nil
+	case "=":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("= expects at least two arguments in %s", term)
+		}
+		pairs := make([]ast.Expression, 0, len(args)-1)
+		for i := 0; i+1 < len(args); i++ {
+			right := args[i+1]
+			if i+2 < len(args) {
+				right = ast.Clone(right)
+			}
+			pairs = append(pairs, infix("<->", args[i], right))
+		}
+		return leftAssociative("*", pairs), nil
+	case "distinct":
+		if len(args) < 2 {
+			return nil, fmt.Errorf("distinct expects at least two arguments in %s", term)
+		}
+		pairs := make([]ast.Expression, 0)
+		for i := range args {
+			for j := i + 1; j < len(args); j++ {
+				pairs = append(pairs, infix("^", ast.Clone(args[i]), ast.Clone(args[j])))
+			}
+		}
+		return leftAssociative("*", pairs), nil
+	case "ite":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("ite expects three arguments in %s", term)
+		}
+		return infix("+", infix("*", args[0], args[1]), infix("*", negation(ast.Clone(args[0])), args[2])), nil
+	}
+
+	return nil, fmt.Errorf("unsupported function %s", head)
+}
+
+// let binds all variables in parallel, each value is read in the outer scope.
+func (r *reader) let(term *sexpr, scope map[string]ast.Expression) (ast.Expression, error) {
+	if len(term.list) != 3 || !term.list[1].isList {
+		return nil, fmt.Errorf("malformed %s", term)
+	}
+
+	inner := make(map[string]ast.Expression)
+	for name, value := range scope {
+		inner[name] = value
+	}
+	for _, binding := range term.list[1].list {
+		if !binding.isList || len(binding.list) != 2 || binding.list[0].isList {
+			return nil, fmt.Errorf("malformed binding %s", binding)
+		}
+		value, err := r.term(binding.list[1], scope)
+		if err != nil {
+			return nil, err
+		}
+		inner[binding.list[0].atom] = value
+	}
+
+	return r.term(term.list[2], inner)
+}
+
+func negation(expression ast.Expression) ast.Expression {
+	return &ast.PrefixExpression{Op: "!", Right: expression}
+}
+
+func infix(op string, left ast.Expression, right ast.Expression) ast.Expression {
+	action, ok := tokenizer.ACTIONS[op]
+	if !ok {
+		action = op
+	}
+	return &ast.InfixExpression{Op: op, Action: action, Left: left, Right: right}
+}
+
+func leftAssociative(op string, operands []ast.Expression) ast.Expression {
+	result := operands[0]
+	for _, operand := range operands[1:] {
+		result = infix(op, result, operand)
+	}
+	return result
+}
+
+// identifierFor turns an SMT-LIB symbol into a name the logix tokenizer reads
+// back as a single identifier.
+func identifierFor(symbol string) string {
+	var builder strings.Builder
+	for i, r := range symbol {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+			builder.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				builder.WriteRune('_')
+			}
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune('_')
+		}
+	}
+	name := builder.String()
+	if name == "" {
+		name = "_"
+	}
+	if tokenizer.Contains(tokenizer.KEYWORDS, tokenizer.TokenKind(name)) {
+		name += "_"
+	}
+	return name
+}
+
+func readAll(source string) ([]*sexpr, error) {
+	runes := []rune(source)
+	i, line := 0, 1
+	stack := make([]*sexpr, 0)
+	result := make([]*sexpr, 0)
+
+	push := func(s *sexpr) {
+		if len(stack) == 0 {
+			result = append(result, s)
+		} else {
+			top := stack[len(stack)-1]
+			top.list = append(top.list, s)
+		}
+	}
+
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == ';':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '(':
+			stack = append(stack, &sexpr{isList: true, line: line})
+			i++
+		case r == ')':
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("line %d: unbalanced )", line)
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			push(top)
+			i++
+		case r == '|' || r == '"':
+			start, startLine := i+1, line
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated %c", startLine, r)
+			}
+			push(&sexpr{atom: string(runes[start:i]), quoted: true, line: startLine})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune("();|\"", runes[i]) {
+				i++
+			}
+			push(&sexpr{atom: string(runes[start:i]), line: line})
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("line %d: unbalanced (", stack[len(stack)-1].line)
+	}
+	return result, nil
+}
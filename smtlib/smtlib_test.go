@@ -0,0 +1,157 @@
+package smtlib
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+var testIdentifiers = []string{"a", "b", "c", "and", "x1"}
+
+func randomExpression(r *rand.Rand, depth int) ast.Expression {
+	if depth == 0 || r.Intn(4) == 0 {
+		if r.Intn(10) == 0 {
+			return &ast.Boolean{Value: r.Intn(2) == 0}
+		}
+		return &ast.Identifier{Value: testIdentifiers[r.Intn(len(testIdentifiers))]}
+	}
+	if r.Intn(4) == 0 {
+		return negation(randomExpression(r, depth-1))
+	}
+	operators := []string{"+", "*", "->", "<->", "^"}
+	return infix(operators[r.Intn(len(operators))], randomExpression(r, depth-1), randomExpression(r, depth-1))
+}
+
+func evaluate(expression ast.Expression, assignment map[string]bool) bool {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return assignment[expr.Value]
+	case *ast.Boolean:
+		return expr.Value
+	case *ast.PrefixExpression:
+		return !evaluate(expr.Right, assignment)
+	case *ast.InfixExpression:
+		left, right := evaluate(expr.Left, assignment), evaluate(expr.Right, assignment)
+		switch expr.Op {
+		case "+":
+			return left || right
+		case "*":
+			return left && right
+		case "->":
+			return !left || right
+		case "<->":
+			return left == right
+		}
+		return left != right
+	}
+	panic("unreachable")
+}
+
+// sameFunction reports whether the expressions agree under every assignment
+// to the identifiers, names map an identifier of left to one of right.
+func sameFunction(left, right ast.Expression, names map[string]string) bool {
+	for bits := 0; bits < 1<<len(testIdentifiers); bits++ {
+		leftAssignment := make(map[string]bool)
+		rightAssignment := make(map[string]bool)
+		for i, ident := range testIdentifiers {
+			leftAssignment[ident] = bits&(1<<i) != 0
+			rightAssignment[names[ident]] = bits&(1<<i) != 0
+		}
+		if evaluate(left, leftAssignment) != evaluate(right, rightAssignment) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		expression := randomExpression(r, 5)
+		script, err := Read(Write(expression, testIdentifiers))
+		if err != nil {
+			t.Fatalf("%s: %v\n%s", expression.Literal(), err, Write(expression, testIdentifiers))
+		}
+		if len(script.Identifiers) != len(testIdentifiers) {
+			t.Fatalf("%s: declared %v", expression.Literal(), script.Identifiers)
+		}
+		if !sameFunction(expression, script.Conjunction(), script.Declarations) {
+			t.Fatalf("%s read back as %s", expression.Literal(), script.Conjunction().Literal())
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	declarations := "(declare-const a Bool) (declare-const b Bool) (declare-fun c () Bool) (declare-const and Bool) (declare-const x1 Bool)\n"
+	names := map[string]string{"a": "a", "b": "b", "c": "c", "and": "and", "x1": "x1"}
+	cases := []struct {
+		term string
+		want ast.Expression
+	}{
+		{"(and a b c)", infix("*", infix("*", id("a"), id("b")), id("c"))},
+		{"(or a b c)", infix("+", infix("+", id("a"), id("b")), id("c"))},
+		{"(and)", &ast.Boolean{Value: true}},
+		{"(=> a b c)", infix("->", id("a"), infix("->", id("b"), id("c")))},
+		{"(xor a b c)", infix("^", infix("^", id("a"), id("b")), id("c"))},
+		{"(= a b c)", infix("*", infix("<->", id("a"), id("b")), infix("<->", id("b"), id("c")))},
+		{"(distinct a b)", infix("^", id("a"), id("b"))},
+		{"(ite a b c)", infix("+", infix("*", id("a"), id("b")), infix("*", negation(id("a")), id("c")))},
+		{"(let ((p (and a b)) (q c)) (or p q))", infix("+", infix("*", id("a"), id("b")), id("c"))},
+		{"(let ((a b)) (let ((b a)) (xor a b)))", infix("^", id("b"), id("b"))},
+		{"(! (not |and|) :named n)", negation(id("and"))},
+	}
+	for _, c := range cases {
+		script, err := Read(declarations + "(assert " + c.term + ")")
+		if err != nil {
+			t.Fatalf("%s: %v", c.term, err)
+		}
+		if got := script.Conjunction(); !sameFunction(got, c.want, names) {
+			t.Errorf("%s read as %s, want %s", c.term, got.Literal(), c.want.Literal())
+		}
+	}
+
+	script, err := Read("(declare-const |a b| Bool) (declare-const a_b Bool) (assert (and |a b| a_b))")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := script.Conjunction().Literal(); got != "(a_b * a_b_1)" {
+		t.Errorf("renamed to %s, want (a_b * a_b_1)", got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	cases := map[string]string{
+		"(declare-const x Int)":                     "only Bool constants",
+		"(declare-fun f (Bool) Bool)":               "only nullary Bool functions",
+		"(define-fun f ((x Bool)) Bool x)":          "only nullary Bool definitions",
+		"(declare-const a Bool) (assert (f a))":     "unsupported function f",
+		"(assert a)":                                "unknown symbol a",
+		"(declare-const a Bool) (assert (not a a))": "not expects one argument",
+		"(push 1)":              "unsupported command push",
+		"(declare-const a Bool": "",
+	}
+	for source, want := range cases {
+		_, err := Read(source)
+		if err == nil {
+			t.Errorf("%s was accepted", source)
+			continue
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %q, want one mentioning %q", source, err, want)
+		}
+	}
+}
+
+func TestWriteQuotesReservedSymbols(t *testing.T) {
+	script := Write(infix("*", id("and"), id("Bool")), []string{"and", "Bool"})
+	for _, want := range []string{"(declare-const |and| Bool)", "(declare-const |Bool| Bool)", "(assert (and |and| |Bool|))"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("%s does not contain %s", script, want)
+		}
+	}
+}
+
+func id(name string) ast.Expression {
+	return &ast.Identifier{Value: name}
+}
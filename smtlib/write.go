@@ -0,0 +1,90 @@
+package smtlib
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"strings"
+)
+
+// reserved lists the words a simple symbol may not be spelled as, together
+// with the names of the Core theory functions.
+var reserved = []string{
+	"and", "or", "not", "xor", "=>", "=", "ite", "let", "distinct", "true", "false",
+	"par", "NUMERAL", "DECIMAL", "STRING", "_", "!", "as", "exists", "forall", "match",
+	"assert", "check-sat", "declare-const", "declare-fun", "define-fun", "exit",
+	"get-model", "set-logic", "set-info", "set-option", "Bool",
+}
+
+// Write prints the expression as an SMT-LIB 2 script that declares every
+// identifier as a Bool constant, asserts the expression and checks it.
+func Write(expression ast.Expression, identifiers []string) string {
+	var builder strings.Builder
+	builder.WriteString("(set-logic QF_UF)\n")
+	for _, ident := range identifiers {
+		fmt.Fprintf(&builder, "(declare-const %s Bool)\n", Symbol(ident))
+	}
+	fmt.Fprintf(&builder, "(assert %s)\n", Term(expression))
+	builder.WriteString("(check-sat)\n")
+	return builder.String()
+}
+
+// Term prints the expression as an SMT-LIB 2 term, chains of the same
+// associative operator are flattened into one application.
+func Term(expression ast.Expression) string {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return Symbol(expr.Value)
+	case *ast.Boolean:
+		if expr.Value {
+			return "true"
+		}
+		return "false"
+	case *ast.PrefixExpression:
+		return fmt.Sprintf("(not %s)", Term(expr.Right))
+	case *ast.InfixExpression:
+		function := functionName(expr.Op)
+		if function == "and" || function == "or" || function == "xor" {
+			operands := make([]string, 0)
+			for _, operand := range flatten(expr, expr.Op) {
+				operands = append(operands, Term(operand))
+			}
+			return fmt.Sprintf("(%s %s)", function, strings.Join(operands, " "))
+		}
+		return fmt.Sprintf("(%s %s %s)", function, Term(expr.Left), Term(expr.Right))
+	}
+
+	panic("unreachable")
+}
+
+// Symbol quotes identifiers that collide with reserved words.
+func Symbol(name string) string {
+	for _, word := range reserved {
+		if word == name {
+			return "|" + name + "|"
+		}
+	}
+	return name
+}
+
+func functionName(op string) string {
+	switch op {
+	case "+", "|":
+		return "or"
+	case "*", "&":
+		return "and"
+	case "->":
+		return "=>"
+	case "<->":
+		return "="
+	case "^":
+		return "xor"
+	}
+	panic("unreachable")
+}
+
+func flatten(expression ast.Expression, op string) []ast.Expression {
+	if expr, ok := expression.(*ast.InfixExpression); ok && functionName(expr.Op) == functionName(op) {
+		return append(flatten(expr.Left, op), flatten(expr.Right, op)...)
+	}
+	return []ast.Expression{expression}
+}
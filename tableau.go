@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"strings"
+)
+
+// tableauEntry is a formula on a tableau branch, numbered in the order it
+// was introduced.
+type tableauEntry struct {
+	number     int
+	expression ast.Expression
+}
+
+// tableauNode introduces formulas to a branch, either the root formula or
+// the components of a rule applied to an earlier entry.
+type tableauNode struct {
+	entries  []tableauEntry
+	rule     string
+	source   int
+	children []*tableauNode
+
+	closed   bool
+	conflict string
+	model    map[string]bool
+}
+
+// Tableau is an analytic tableau for the negation of a formula, the formula
+// is valid exactly when every branch closes.
+type Tableau struct {
+	Expression ast.Expression
+	root       *tableauNode
+	next       int
+}
+
+func NewTableau(expression ast.Expression) *Tableau {
+	t := &Tableau{Expression: expression, next: 1}
+	negated := tableauEntry{number: t.next, expression: negate(expression)}
+	t.next++
+	t.root = &tableauNode{entries: []tableauEntry{negated}, rule: "negated goal"}
+	t.expand(t.root, []tableauEntry{negated}, make(map[string]bool))
+	return t
+}
+
+// Closed reports whether every branch of the tableau is closed.
+func (t *Tableau) Closed() bool {
+	return t.Countermodel() == nil
+}
+
+// Countermodel returns the assignment read off the first open branch, which
+// makes the formula false, or nil when all branches are closed.
+func (t *Tableau) Countermodel() map[string]bool {
+	var find func(*tableauNode) map[string]bool
+	find = func(n *tableauNode) map[string]bool {
+		if n.model != nil {
+			return n.model
+		}
+		for _, child := range n.children {
+			if model := find(child); model != nil {
+				return model
+			}
+		}
+		return nil
+	}
+	return find(t.root)
+}
+
+// expand checks the formulas new at the node for a contradiction and then
+// applies a rule to one pending formula, preferring alpha rules so that
+// branching is postponed.
+func (t *Tableau) expand(n *tableauNode, pending []tableauEntry, literals map[string]bool) {
+	for _, entry := range n.entries {
+		name, value, ok := tableauLiteral(entry.expression)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			if !value {
+				n.closed, n.conflict = true, entry.expression.Literal()
+				return
+			}
+			continue
+		}
+		if previous, seen := literals[name]; seen && previous != value {
+			n.closed, n.conflict = true, fmt.Sprintf("%s and !%s", name, name)
+			return
+		}
+		literals[name] = value
+	}
+
+	chosen := -1
+	var branches [][]ast.Expression
+	var rule string
+	for i, entry := range pending {
+		if b, r := tableauRule(entry.expression); b != nil && (chosen == -1 || (len(b) == 1 && len(branches) > 1)) {
+			chosen, branches, rule = i, b, r
+			if len(b) == 1 {
+				break
+			}
+		}
+	}
+
+	if chosen == -1 {
+		n.model = make(map[string]bool)
+		for _, ident := range getAllIdentifiers(t.Expression, nil) {
+			n.model[ident] = literals[ident]
+		}
+		return
+	}
+
+	source := pending[chosen].number
+	rest := append(append([]tableauEntry{}, pending[:chosen]...), pending[chosen+1:]...)
+	for _, components := range branches {
+		child := &tableauNode{rule: rule, source: source}
+		for _, component := range components {
+			child.entries = append(child.entries, tableauEntry{number: t.next, expression: component})
+			t.next++
+		}
+		n.children = append(n.children, child)
+
+		branchLiterals := make(map[string]bool)
+		for name, value := range literals {
+			branchLiterals[name] = value
+		}
+		t.expand(child, append(append([]tableauEntry{}, rest...), child.entries...), branchLiterals)
+	}
+}
+
+// tableauLiteral recognises identifiers, constants and their negations. The
+// name is empty for constants.
+func tableauLiteral(expression ast.Expression) (string, bool, bool) {
+	value := true
+	if prefix, ok := expression.(*ast.PrefixExpression); ok && prefix.Op == "!" {
+		if _, nested := prefix.Right.(*ast.PrefixExpression); nested {
+			return "", false, false
+		}
+		expression, value = prefix.Right, false
+	}
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return expr.Value, value, true
+	case *ast.Boolean:
+		return "", expr.Value == value, true
+	}
+	return "", false, false
+}
+
+// tableauRule returns the branches a formula expands into, one branch for
+// alpha rules and two for beta rules, with the name of the rule. Literals
+// give no branches.
+func tableauRule(expression ast.Expression) ([][]ast.Expression, string) {
+	negated := false
+	if prefix, ok := expression.(*ast.PrefixExpression); ok && prefix.Op == "!" {
+		if inner, ok := prefix.Right.(*ast.PrefixExpression); ok && inner.Op == "!" {
+			return [][]ast.Expression{{inner.Right}}, "α ¬¬"
+		}
+		expression, negated = prefix.Right, true
+	}
+
+	infix, ok := expression.(*ast.InfixExpression)
+	if !ok {
+		return nil, ""
+	}
+	a, b := infix.Left, infix.Right
+
+	switch infix.Op {
+	case "*", "&":
+		if negated {
+			return [][]ast.Expression{{negate(a)}, {negate(b)}}, "β ¬∧"
+		}
+		return [][]ast.Expression{{a, b}}, "α ∧"
+	case "+", "|":
+		if negated {
+			return [][]ast.Expression{{negate(a), negate(b)}}, "α ¬∨"
+		}
+		return [][]ast.Expression{{a}, {b}}, "β ∨"
+	case "->":
+		if negated {
+			return [][]ast.Expression{{a, negate(b)}}, "α ¬→"
+		}
+		return [][]ast.Expression{{negate(a)}, {b}}, "β →"
+	case "<->":
+		if negated {
+			return [][]ast.Expression{{a, negate(b)}, {negate(a), b}}, "β ¬↔"
+		}
+		return [][]ast.Expression{{a, b}, {negate(a), negate(b)}}, "β ↔"
+	case "^":
+		if negated {
+			return [][]ast.Expression{{a, b}, {negate(a), negate(b)}}, "β ¬⊕"
+		}
+		return [][]ast.Expression{{a, negate(b)}, {negate(a), b}}, "β ⊕"
+	}
+	return nil, ""
+}
+
+func negate(expression ast.Expression) ast.Expression {
+	return &ast.PrefixExpression{Op: "!", Right: expression}
+}
+
+// Render draws the tableau as an indented tree for the terminal.
+func (t *Tableau) Render() string {
+	lines := make([]string, 0)
+	var render func(n *tableauNode, prefix string, childPrefix string)
+	render = func(n *tableauNode, prefix string, childPrefix string) {
+		for i, entry := range n.entries {
+			justification := n.rule
+			if n.source > 0 {
+				justification = fmt.Sprintf("%s from %d", n.rule, n.source)
+			}
+			line := fmt.Sprintf("%d. %s   [%s]", entry.number, entry.expression.Literal(), justification)
+			if i == 0 {
+				lines = append(lines, prefix+line)
+			} else {
+				lines = append(lines, childPrefix+line)
+			}
+		}
+		switch {
+		case n.closed:
+			lines = append(lines, childPrefix+fmt.Sprintf("\033[31m× closed\033[0m (%s)", n.conflict))
+		case n.model != nil:
+			lines = append(lines, childPrefix+fmt.Sprintf("\033[32m○ open\033[0m, countermodel: %s", formatAssignment(getAllIdentifiers(t.Expression, nil), n.model)))
+		}
+		for i, child := range n.children {
+			if i == len(n.children)-1 {
+				render(child, childPrefix+"└─ ", childPrefix+"   ")
+			} else {
+				render(child, childPrefix+"├─ ", childPrefix+"│  ")
+			}
+		}
+	}
+	render(t.root, "", "")
+	return strings.Join(lines, "\n")
+}
+
+// DOT renders the tableau as a Graphviz digraph, one box per node with the
+// formulas it introduces.
+func (t *Tableau) DOT() string {
+	var builder strings.Builder
+	builder.WriteString("digraph tableau {\n")
+	builder.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+
+	id := 0
+	var render func(n *tableauNode) int
+	render = func(n *tableauNode) int {
+		current := id
+		id++
+		labels := make([]string, 0, len(n.entries))
+		for _, entry := range n.entries {
+			labels = append(labels, fmt.Sprintf("%d. %s", entry.number, entry.expression.Literal()))
+		}
+		rule := n.rule
+		if n.source > 0 {
+			rule = fmt.Sprintf("%s from %d", n.rule, n.source)
+		}
+		labels = append(labels, fmt.Sprintf("[%s]", rule))
+		fmt.Fprintf(&builder, "  n%d [label=%q];\n", current, strings.Join(labels, "\n"))
+
+		switch {
+		case n.closed:
+			fmt.Fprintf(&builder, "  n%d_end [label=%q, shape=plaintext, fontcolor=red];\n  n%d -> n%d_end;\n", current, "× "+n.conflict, current, current)
+		case n.model != nil:
+			parts := make([]string, 0)
+			for _, ident := range getAllIdentifiers(t.Expression, nil) {
+				value := "0"
+				if n.model[ident] {
+					value = "1"
+				}
+				parts = append(parts, ident+"="+value)
+			}
+			fmt.Fprintf(&builder, "  n%d_end [label=%q, shape=plaintext, fontcolor=darkgreen];\n  n%d -> n%d_end;\n", current, "○ "+strings.Join(parts, " "), current, current)
+		}
+
+		for _, child := range n.children {
+			fmt.Fprintf(&builder, "  n%d -> n%d;\n", current, render(child))
+		}
+		return current
+	}
+	render(t.root)
+
+	builder.WriteString("}")
+	return builder.String()
+}
+
+func formatTableau(expression ast.Expression, dot bool) string {
+	t := NewTableau(expression)
+	if dot {
+		return t.DOT()
+	}
+	verdict := bold("valid") + ", every branch is closed"
+	if !t.Closed() {
+		verdict = fmt.Sprintf("%s countermodel: %s", bold("not valid,"), formatAssignment(getAllIdentifiers(expression, nil), t.Countermodel()))
+	}
+	return t.Render() + "\n" + verdict
+}
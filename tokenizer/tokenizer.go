@@ -17,14 +17,63 @@ const (
 	TOK_BANG        TokenKind = "bang"
 	TOK_AND         TokenKind = "and"
 	TOK_OR          TokenKind = "or"
+	TOK_XOR         TokenKind = "xor"
 	TOK_IMPLICATION TokenKind = "implication"
 	TOK_BICONDITION TokenKind = "bicondition"
 	TOK_EQ          TokenKind = "eq"
 	TOK_NEQ         TokenKind = "neq"
 	TOK_INTRODUCE   TokenKind = "introduce"
 	TOK_TABLE       TokenKind = "table"
+	TOK_SAT         TokenKind = "sat"
+	TOK_MODELS      TokenKind = "models"
+	TOK_COUNT       TokenKind = "count"
+	TOK_OVER        TokenKind = "over"
+	TOK_COMMA       TokenKind = "comma"
+	TOK_VALID       TokenKind = "valid"
+	TOK_UNSAT       TokenKind = "unsat"
+	TOK_CLASSIFY    TokenKind = "classify"
+	TOK_PREMISES    TokenKind = "premises"
+	TOK_ENTAILS     TokenKind = "entails"
+	TOK_CONSISTENT  TokenKind = "consistent"
+	TOK_CORE        TokenKind = "core"
+	TOK_PROVE       TokenKind = "prove"
+	TOK_TABLEAU     TokenKind = "tableau"
+	TOK_DOT         TokenKind = "dot"
+	TOK_CHECK       TokenKind = "check"
+	TOK_DERIVE      TokenKind = "derive"
+	TOK_BY          TokenKind = "by"
+	TOK_EXPLAIN     TokenKind = "explain"
+	TOK_CHECKED     TokenKind = "checked"
+	TOK_RULE        TokenKind = "rule"
+	TOK_LOAD        TokenKind = "load"
+	TOK_SATURATE    TokenKind = "saturate"
+	TOK_NNF         TokenKind = "nnf"
+	TOK_ANF         TokenKind = "anf"
+	TOK_NAND        TokenKind = "nand"
+	TOK_NOR         TokenKind = "nor"
+	TOK_SHARED      TokenKind = "shared"
+	TOK_CLASSES     TokenKind = "classes"
+	TOK_COMPLETE    TokenKind = "complete"
+	TOK_LBRACE      TokenKind = "lbrace"
+	TOK_RBRACE      TokenKind = "rbrace"
+	TOK_COLON       TokenKind = "colon"
+	TOK_REWRITE     TokenKind = "rewrite"
+	TOK_JSON        TokenKind = "json"
+	TOK_LATEX       TokenKind = "latex"
+	TOK_EQUALS      TokenKind = "equals"
+	TOK_TURNSTILE   TokenKind = "turnstile"
+	TOK_BDD         TokenKind = "bdd"
+	TOK_STATS       TokenKind = "stats"
+	TOK_IMPORT      TokenKind = "import"
+	TOK_EXPORT      TokenKind = "export"
+	TOK_DIMACS      TokenKind = "dimacs"
+	TOK_SMTLIB      TokenKind = "smtlib"
+	TOK_TO          TokenKind = "to"
 )
 
+// KEYWORDS are the reserved words. The words of the other statements and
+// their clauses are tokenized as identifiers, the parser recognizes them by
+// position, so they stay usable as variables.
 var KEYWORDS = []TokenKind{
 	TOK_INTRODUCE,
 	TOK_SIMPLIFY,
@@ -38,6 +87,7 @@ var ACTIONS = map[string]string{
 	"&": string(TOK_AND),
 	"+": string(TOK_OR),
 	"|": string(TOK_OR),
+	"^": string(TOK_XOR),
 }
 
 type Tokenizer struct {
@@ -94,6 +144,14 @@ func (t *Tokenizer) Tokenize() error {
 			token.Kind = TOK_LPAREN
 		case ')':
 			token.Kind = TOK_RPAREN
+		case '{':
+			token.Kind = TOK_LBRACE
+		case '}':
+			token.Kind = TOK_RBRACE
+		case ',':
+			token.Kind = TOK_COMMA
+		case ':':
+			token.Kind = TOK_COLON
 		case '<':
 			if t.nextChar == '-' {
 				t.Next()
@@ -119,14 +177,32 @@ func (t *Tokenizer) Tokenize() error {
 			}
 		case '&', '*':
 			token.Kind = TOK_AND
-		case '|', '+':
+		case '|':
+			if t.nextChar == '-' {
+				t.Next()
+				token.Kind = TOK_TURNSTILE
+				token.Literal = "|-"
+				token.Length = 2
+			} else {
+				token.Kind = TOK_OR
+			}
+		case '+':
 			token.Kind = TOK_OR
+		case '^':
+			token.Kind = TOK_XOR
 		case '=':
 			if t.nextChar == '=' {
 				t.Next()
 				token.Kind = TOK_EQ
 				token.Literal = "=="
 				token.Length = 2
+			} else if t.nextChar == '>' {
+				t.Next()
+				token.Kind = TOK_REWRITE
+				token.Literal = "=>"
+				token.Length = 2
+			} else {
+				token.Kind = TOK_EQUALS
 			}
 		case '0':
 			token.Kind = TOK_FALSE
@@ -0,0 +1,40 @@
+package tokenizer
+
+import (
+	"slices"
+	"testing"
+)
+
+func kinds(t *testing.T, source string) []TokenKind {
+	t.Helper()
+	tok := NewTokenizer(source)
+	if err := tok.Tokenize(); err != nil {
+		t.Fatal(err)
+	}
+	result := make([]TokenKind, 0, len(tok.Tokens))
+	for _, token := range tok.Tokens {
+		result = append(result, token.Kind)
+	}
+	return result
+}
+
+func TestTokenize(t *testing.T) {
+	cases := map[string][]TokenKind{
+		"a -> b":   {TOK_IDENT, TOK_IMPLICATION, TOK_IDENT},
+		"a - b":    {TOK_IDENT, TOK_ILLEGAL, TOK_IDENT},
+		"-":        {TOK_ILLEGAL},
+		"a <-> b":  {TOK_IDENT, TOK_BICONDITION, TOK_IDENT},
+		"a = b":    {TOK_IDENT, TOK_EQUALS, TOK_IDENT},
+		"a == b":   {TOK_IDENT, TOK_EQ, TOK_IDENT},
+		"a => b":   {TOK_IDENT, TOK_REWRITE, TOK_IDENT},
+		"a |- b":   {TOK_IDENT, TOK_TURNSTILE, TOK_IDENT},
+		"!a != 1":  {TOK_BANG, TOK_IDENT, TOK_NEQ, TOK_TRUE},
+		"sat over": {TOK_IDENT, TOK_IDENT},
+		"table x":  {TOK_TABLE, TOK_IDENT},
+	}
+	for source, want := range cases {
+		if got := kinds(t, source); !slices.Equal(got, want) {
+			t.Errorf("%q tokenized as %v, want %v", source, got, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"strings"
+)
+
+// TraceStep is one rule application of a simplification: the rule replaced
+// the subterm Matched by Replacement, leaving Formula as the whole formula.
+type TraceStep struct {
+	Rule        string
+	Matched     ast.Expression
+	Replacement ast.Expression
+	Formula     ast.Expression
+}
+
+// Trace is the sequence of rule applications leading from Input to Result,
+// Stop tells why the simplification ended.
+type Trace struct {
+	Input  ast.Expression
+	Steps  []TraceStep
+	Result ast.Expression
+	Stop   StopReason
+}
+
+// Text renders the trace with one numbered step per rule application.
+func (t *Trace) Text() string {
+	width := 0
+	for _, step := range t.Steps {
+		width = max(width, len(step.Rule))
+	}
+
+	lines := []string{fmt.Sprintf("    %s", t.Input.Literal())}
+	for i, step := range t.Steps {
+		lines = append(lines, fmt.Sprintf("%3d. %-*s  %s  ⇒  %s", i+1, width, step.Rule, step.Matched.Literal(), step.Replacement.Literal()))
+		lines = append(lines, fmt.Sprintf("     = %s", step.Formula.Literal()))
+	}
+	if len(t.Steps) == 0 {
+		lines = append(lines, "no rule applies")
+	}
+	if t.Stop != Fixpoint {
+		lines = append(lines, fmt.Sprintf("stopped by the %s, the result is %s", t.Stop, t.Result.Literal()))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type jsonTraceStep struct {
+	Rule        string `json:"rule"`
+	Matched     string `json:"matched"`
+	Replacement string `json:"replacement"`
+	Formula     string `json:"formula"`
+}
+
+type jsonTrace struct {
+	Input  string          `json:"input"`
+	Steps  []jsonTraceStep `json:"steps"`
+	Result string          `json:"result"`
+	Stop   string          `json:"stop"`
+}
+
+// JSON renders the trace as a JSON object with the formulas as literals.
+func (t *Trace) JSON() (string, error) {
+	document := jsonTrace{Input: t.Input.Literal(), Steps: make([]jsonTraceStep, 0, len(t.Steps)), Result: t.Result.Literal(), Stop: string(t.Stop)}
+	for _, step := range t.Steps {
+		document.Steps = append(document.Steps, jsonTraceStep{
+			Rule:        step.Rule,
+			Matched:     step.Matched.Literal(),
+			Replacement: step.Replacement.Literal(),
+			Formula:     step.Formula.Literal(),
+		})
+	}
+	var builder strings.Builder
+	encoder := json.NewEncoder(&builder)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(builder.String(), "\n"), nil
+}
+
+// LaTeX renders the trace as an align* block with the rule of every step
+// as a comment in the second column.
+func (t *Trace) LaTeX() string {
+	lines := []string{"\\begin{align*}", fmt.Sprintf("  & %s", latexExpression(t.Input))}
+	for _, step := range t.Steps {
+		lines[len(lines)-1] += " \\\\"
+		lines = append(lines, fmt.Sprintf("  &= %s && \\text{%s}", latexExpression(step.Formula), latexText.Replace(step.Rule)))
+	}
+	lines = append(lines, "\\end{align*}")
+	return strings.Join(lines, "\n")
+}
+
+// latexText escapes the characters TeX treats specially in running text.
+var latexText = strings.NewReplacer(
+	"\\", "\\textbackslash{}",
+	"{", "\\{",
+	"}", "\\}",
+	"_", "\\_",
+	"&", "\\&",
+	"%", "\\%",
+	"#", "\\#",
+	"$", "\\$",
+	"~", "\\textasciitilde{}",
+	"^", "\\textasciicircum{}",
+)
+
+func latexExpression(expression ast.Expression) string {
+	switch expr := expression.(type) {
+	case *ast.Identifier:
+		return strings.ReplaceAll(expr.Value, "_", "\\_")
+	case *ast.Boolean:
+		return expr.Literal()
+	case *ast.PrefixExpression:
+		return "\\neg " + latexExpression(expr.Right)
+	case *ast.InfixExpression:
+		operators := map[string]string{
+			"+":   "\\lor",
+			"|":   "\\lor",
+			"*":   "\\land",
+			"&":   "\\land",
+			"->":  "\\to",
+			"<->": "\\leftrightarrow",
+			"^":   "\\oplus",
+		}
+		return fmt.Sprintf("(%s %s %s)", latexExpression(expr.Left), operators[expr.Op], latexExpression(expr.Right))
+	}
+	return expression.Literal()
+}
+
+func formatTrace(trace *Trace, format string) string {
+	switch format {
+	case "json":
+		encoded, err := trace.JSON()
+		if err != nil {
+			return err.Error()
+		}
+		return encoded
+	case "latex":
+		return trace.LaTeX()
+	}
+	return trace.Text()
+}
@@ -0,0 +1,16 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"strings"
+	"testing"
+)
+
+func TestLaTeXEscapesRuleNames(t *testing.T) {
+	a := &ast.Identifier{Value: "a"}
+	trace := &Trace{Input: a, Steps: []TraceStep{{Rule: `my_rule & 50% #1 \ {x}`, Formula: a}}}
+	want := `\text{my\_rule \& 50\% \#1 \textbackslash{} \{x\}}`
+	if got := trace.LaTeX(); !strings.Contains(got, want) {
+		t.Errorf("LaTeX trace\n%s\ndoes not contain %s", got, want)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"github.com/terawatthour/logix/ast"
+	"strings"
+)
+
+// universalGate is a universal gate written with the existing operators,
+// NAND as !(a * b) and NOR as !(a + b).
+type universalGate struct {
+	name   string
+	op     string
+	action string
+}
+
+var universalGates = map[string]universalGate{
+	"nand": {name: "NAND", op: "*", action: "and"},
+	"nor":  {name: "NOR", op: "+", action: "or"},
+}
+
+func (g universalGate) gate(left ast.Expression, right ast.Expression) ast.Expression {
+	return &ast.PrefixExpression{Op: "!", Right: &ast.InfixExpression{Op: g.op, Action: g.action, Left: left, Right: right}}
+}
+
+// isGate reports whether the expression is a gate and returns its inputs.
+func (g universalGate) isGate(expression ast.Expression) (ast.Expression, ast.Expression, bool) {
+	prefix, ok := expression.(*ast.PrefixExpression)
+	if !ok || prefix.Op != "!" {
+		return nil, nil, false
+	}
+	infix, ok := prefix.Right.(*ast.InfixExpression)
+	if !ok || infix.Action != g.action {
+		return nil, nil, false
+	}
+	return infix.Left, infix.Right, true
+}
+
+// not builds an inverter from a gate with both inputs tied, an inverted
+// inverter is removed.
+func (g universalGate) not(expression ast.Expression) ast.Expression {
+	if left, right, ok := g.isGate(expression); ok && sameExpression(left, right) {
+		return left
+	}
+	return g.gate(expression, expression)
+}
+
+// native builds the connective the gate negates, and for NAND or for NOR.
+func (g universalGate) native(left ast.Expression, right ast.Expression) ast.Expression {
+	return g.not(g.gate(left, right))
+}
+
+// dual builds the other connective by De Morgan, or for NAND and for NOR.
+func (g universalGate) dual(left ast.Expression, right ast.Expression) ast.Expression {
+	return g.gate(g.not(left), g.not(right))
+}
+
+// fourGates is the four gate circuit computing exclusive or from NAND gates
+// and its negation from NOR gates.
+func (g universalGate) fourGates(left ast.Expression, right ast.Expression) ast.Expression {
+	shared := g.gate(left, right)
+	return g.gate(g.gate(left, shared), g.gate(right, shared))
+}
+
+func (g universalGate) convert(expression ast.Expression) ast.Expression {
+	switch expr := expression.(type) {
+	case *ast.Identifier, *ast.Boolean:
+		return ast.Clone(expr)
+	case *ast.PrefixExpression:
+		return g.not(g.convert(expr.Right))
+	case *ast.NaryExpression:
+		return g.convert(ast.Binary(expr))
+	case *ast.InfixExpression:
+		left, right := g.convert(expr.Left), g.convert(expr.Right)
+		// the four gate circuit gives xor for NAND and xnor for NOR
+		exclusive := g.action == "and"
+		switch expr.Action {
+		case g.action:
+			return g.native(left, right)
+		case dualAction(g.action):
+			return g.dual(left, right)
+		case "->":
+			if g.action == "and" {
+				return g.gate(left, g.not(right))
+			}
+			return g.native(g.not(left), right)
+		case "xor":
+			if exclusive {
+				return g.fourGates(left, right)
+			}
+			return g.not(g.fourGates(left, right))
+		case "<->":
+			if exclusive {
+				return g.not(g.fourGates(left, right))
+			}
+			return g.fourGates(left, right)
+		}
+	}
+	panic("unreachable")
+}
+
+// UniversalForm rewrites the expression using only the universal gate,
+// "nand" or "nor". Identifiers and constants are the inputs of the circuit.
+func UniversalForm(expression ast.Expression, gate string) (ast.Expression, error) {
+	g, ok := universalGates[gate]
+	if !ok {
+		return nil, fmt.Errorf("unknown gate %s, expected nand or nor", gate)
+	}
+	return g.convert(expression), nil
+}
+
+// countGates counts the gates of a circuit, once per occurrence or, when
+// shared, once per distinct subcircuit. The tied inputs of an inverter are
+// one wire, their subcircuit is counted once either way.
+func (g universalGate) countGates(expression ast.Expression, shared bool) int {
+	seen := make(map[string]bool)
+	count := 0
+	var visit func(ast.Expression)
+	visit = func(expression ast.Expression) {
+		left, right, ok := g.isGate(expression)
+		if !ok {
+			return
+		}
+		if shared {
+			if seen[expression.Literal()] {
+				return
+			}
+			seen[expression.Literal()] = true
+		}
+		count++
+		visit(left)
+		if !sameExpression(left, right) {
+			visit(right)
+		}
+	}
+	visit(expression)
+	return count
+}
+
+// netlist lists every distinct gate once, inputs first, naming the gates
+// g1, g2 and so on.
+func (g universalGate) netlist(expression ast.Expression, idents []string) ([]string, string) {
+	names := make(map[string]string)
+	lines := make([]string, 0)
+	var name func(ast.Expression) string
+	name = func(expression ast.Expression) string {
+		left, right, ok := g.isGate(expression)
+		if !ok {
+			return expression.Literal()
+		}
+		if existing, ok := names[expression.Literal()]; ok {
+			return existing
+		}
+		leftName, rightName := name(left), name(right)
+		gateName := fmt.Sprintf("g%d", len(names)+1)
+		for contains(idents, gateName) {
+			gateName = "_" + gateName
+		}
+		names[expression.Literal()] = gateName
+		lines = append(lines, fmt.Sprintf("%s = !(%s %s %s)", gateName, leftName, g.op, rightName))
+		return gateName
+	}
+	output := name(expression)
+	return lines, output
+}
+
+func formatUniversalForm(expression ast.Expression, gate string, shared bool) string {
+	circuit, err := UniversalForm(expression, gate)
+	if err != nil {
+		return err.Error()
+	}
+	g := universalGates[gate]
+	tree, distinct := g.countGates(circuit, false), g.countGates(circuit, true)
+
+	if !shared {
+		return fmt.Sprintf("%s\n%s", circuit.Literal(), bold(fmt.Sprintf("%d %s gate(s)", tree, g.name)))
+	}
+	lines, output := g.netlist(circuit, getAllIdentifiers(expression, nil))
+	lines = append(lines, fmt.Sprintf("output %s", output))
+	lines = append(lines, bold(fmt.Sprintf("%d %s gate(s) with sharing, %d without", distinct, g.name, tree)))
+	return strings.Join(lines, "\n")
+}
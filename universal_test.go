@@ -0,0 +1,73 @@
+package main
+
+import (
+	"github.com/terawatthour/logix/ast"
+	"math/rand"
+	"testing"
+)
+
+// checkOnlyGates fails when the circuit contains anything but the gate,
+// identifiers and constants.
+func checkOnlyGates(t *testing.T, g universalGate, input, circuit ast.Expression) {
+	t.Helper()
+	switch circuit.(type) {
+	case *ast.Identifier, *ast.Boolean:
+		return
+	}
+	left, right, ok := g.isGate(circuit)
+	if !ok {
+		t.Fatalf("the %s circuit of %s contains %s", g.name, input.Literal(), circuit.Literal())
+	}
+	checkOnlyGates(t, g, input, left)
+	checkOnlyGates(t, g, input, right)
+}
+
+func TestUniversalForm(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		data := make([]byte, r.Intn(14))
+		r.Read(data)
+		expression := fuzzExpression(data)
+		for gate, g := range universalGates {
+			circuit, err := UniversalForm(expression, gate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOnlyGates(t, g, expression, circuit)
+			if equivalent, _ := Equivalent(expression, circuit); !equivalent {
+				t.Fatalf("%s has the inequivalent %s circuit %s", expression.Literal(), g.name, circuit.Literal())
+			}
+			lines, _ := g.netlist(circuit, getAllIdentifiers(expression, nil))
+			if shared, tree := g.countGates(circuit, true), g.countGates(circuit, false); shared != len(lines) || shared > tree {
+				t.Fatalf("the %s circuit of %s counts %d shared and %d tree gates in a netlist of %d", g.name, expression.Literal(), shared, tree, len(lines))
+			}
+		}
+	}
+
+	if _, err := UniversalForm(parseRuleSide("a"), "xor"); err == nil {
+		t.Error("the gate xor was accepted")
+	}
+}
+
+func TestUniversalGateCounts(t *testing.T) {
+	cases := []struct {
+		source string
+		gate   string
+		shared bool
+		want   int
+	}{
+		{"a ^ b", "nand", true, 4},
+		{"a ^ b", "nand", false, 5},
+		{"a <-> b", "nor", true, 4},
+		{"a * b", "nand", true, 2},
+		{"a + b", "nand", true, 3},
+		{"!a", "nor", true, 1},
+		{"!!a", "nand", true, 0},
+	}
+	for _, c := range cases {
+		circuit, _ := UniversalForm(parseRuleSide(c.source), c.gate)
+		if got := universalGates[c.gate].countGates(circuit, c.shared); got != c.want {
+			t.Errorf("%s by %s with sharing %t counts %d gates, want %d", c.source, c.gate, c.shared, got, c.want)
+		}
+	}
+}